@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/packages"
+)
+
+const tracingPkgPath = "github.com/bwplotka/tracing-go/tracing"
+
+type generator struct {
+	typeName string
+	name     string
+	attrs    []string
+
+	pkg *types.Package
+	// imports maps import path to the package name used in the generated code.
+	imports     map[string]string
+	usesTracing bool
+}
+
+// generate returns source code of the decorator for the interface from the package in the given directory.
+func (g *generator) generate(dir string) ([]byte, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		// Type check from source, so the result does not depend on the compiler export data format.
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps,
+		Dir:  dir,
+	}, ".")
+	if err != nil {
+		return nil, errors.Wrap(err, "load package")
+	}
+	if len(pkgs) != 1 {
+		return nil, errors.Errorf("expected exactly one package in %v, got %d", dir, len(pkgs))
+	}
+	if len(pkgs[0].Errors) > 0 {
+		return nil, errors.Wrapf(pkgs[0].Errors[0], "load package %v", pkgs[0].PkgPath)
+	}
+	g.pkg = pkgs[0].Types
+
+	obj := g.pkg.Scope().Lookup(g.typeName)
+	if obj == nil {
+		return nil, errors.Errorf("type %v not found in package %v", g.typeName, g.pkg.Path())
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return nil, errors.Errorf("%v is not a named type", g.typeName)
+	}
+	iface, ok := named.Underlying().(*types.Interface)
+	if !ok {
+		return nil, errors.Errorf("%v is not an interface", g.typeName)
+	}
+	if named.TypeParams().Len() > 0 {
+		return nil, errors.Errorf("generic interface %v is not supported", g.typeName)
+	}
+	if g.name == "" {
+		g.name = "Traced" + g.typeName
+	}
+	g.imports = map[string]string{tracingPkgPath: "tracing"}
+
+	body := &bytes.Buffer{}
+	fmt.Fprintf(body, "var _ %s = &%s{}\n\n", g.typeName, g.name)
+	fmt.Fprintf(body, "// %s decorates %s with tracing spans.\n", g.name, g.typeName)
+	fmt.Fprintf(body, "type %s struct {\n\tnext %s\n}\n\n", g.name, g.typeName)
+	fmt.Fprintf(body, "// New%s returns %s decorator that starts span for each method with context.\n", g.name, g.typeName)
+	fmt.Fprintf(body, "func New%s(next %s) *%s {\n\treturn &%s{next: next}\n}\n", g.name, g.typeName, g.name, g.name)
+	for i := 0; i < iface.NumMethods(); i++ {
+		g.method(body, iface.Method(i))
+	}
+
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "// Code generated by tracinggen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", g.pkg.Name())
+	paths := make([]string, 0, len(g.imports))
+	for p := range g.imports {
+		if p == tracingPkgPath && !g.usesTracing {
+			continue
+		}
+		paths = append(paths, p)
+	}
+	// Standard library packages first, then the others.
+	sort.Slice(paths, func(i, j int) bool {
+		if isStd(paths[i]) != isStd(paths[j]) {
+			return isStd(paths[i])
+		}
+		return paths[i] < paths[j]
+	})
+	for i, p := range paths {
+		if i > 0 && isStd(paths[i-1]) && !isStd(p) {
+			fmt.Fprintf(out, "\n")
+		}
+		if name := g.imports[p]; name != p[strings.LastIndex(p, "/")+1:] {
+			fmt.Fprintf(out, "\t%s %q\n", name, p)
+			continue
+		}
+		fmt.Fprintf(out, "\t%q\n", p)
+	}
+	fmt.Fprintf(out, ")\n\n")
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, errors.Wrapf(err, "format generated code:\n%s", out.String())
+	}
+	return src, nil
+}
+
+// qualifier returns package name for the types from the other packages and records them as imports.
+func (g *generator) qualifier(p *types.Package) string {
+	if p == g.pkg {
+		return ""
+	}
+	if name, ok := g.imports[p.Path()]; ok {
+		return name
+	}
+
+	name := p.Name()
+	for i := 2; g.importedName(name); i++ {
+		name = p.Name() + strconv.Itoa(i)
+	}
+	g.imports[p.Path()] = name
+	return name
+}
+
+func (g *generator) importedName(name string) bool {
+	for _, n := range g.imports {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+func (g *generator) attr(method, param string) bool {
+	for _, a := range g.attrs {
+		if a == param || a == method+"."+param {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) method(w *bytes.Buffer, m *types.Func) {
+	sig := m.Type().(*types.Signature)
+
+	// Receiver, span and result variables can't collide with parameter names.
+	used := map[string]bool{"t": true, "span": true, "tracing": true}
+	for _, n := range g.imports {
+		used[n] = true
+	}
+	unique := func(name string) string {
+		for used[name] {
+			name += "_"
+		}
+		used[name] = true
+		return name
+	}
+
+	params := make([]string, sig.Params().Len())
+	paramDecls := make([]string, sig.Params().Len())
+	for i := range params {
+		p := sig.Params().At(i)
+		params[i] = p.Name()
+		if params[i] == "" || params[i] == "_" {
+			params[i] = fmt.Sprintf("p%d", i)
+			if i == 0 && isContext(p.Type()) {
+				params[i] = "ctx"
+			}
+		}
+		params[i] = unique(params[i])
+
+		typ := g.typeString(p.Type())
+		if sig.Variadic() && i == len(params)-1 {
+			typ = "..." + g.typeString(p.Type().(*types.Slice).Elem())
+		}
+		paramDecls[i] = params[i] + " " + typ
+	}
+
+	results := make([]string, sig.Results().Len())
+	resultTypes := make([]string, sig.Results().Len())
+	returnsErr := false
+	for i := range results {
+		r := sig.Results().At(i)
+		resultTypes[i] = g.typeString(r.Type())
+		if i == len(results)-1 && types.Identical(r.Type(), types.Universe.Lookup("error").Type()) {
+			returnsErr = true
+			results[i] = unique("err")
+			continue
+		}
+		results[i] = unique(fmt.Sprintf("r%d", i))
+	}
+
+	call := fmt.Sprintf("t.next.%s(%s)", m.Name(), strings.Join(params, ", "))
+	if sig.Variadic() {
+		call = strings.TrimSuffix(call, ")") + "...)"
+	}
+
+	withCtx := len(params) > 0 && isContext(sig.Params().At(0).Type())
+	fmt.Fprintf(w, "\nfunc (t *%s) %s(%s)", g.name, m.Name(), strings.Join(paramDecls, ", "))
+	switch {
+	case len(resultTypes) == 0:
+		fmt.Fprintf(w, " {\n")
+	case withCtx && returnsErr:
+		// Named results, so deferred span end sees the returned error.
+		resultDecls := make([]string, len(results))
+		for i := range results {
+			resultDecls[i] = results[i] + " " + resultTypes[i]
+		}
+		fmt.Fprintf(w, " (%s) {\n", strings.Join(resultDecls, ", "))
+	case len(resultTypes) == 1:
+		fmt.Fprintf(w, " %s {\n", resultTypes[0])
+	default:
+		fmt.Fprintf(w, " (%s) {\n", strings.Join(resultTypes, ", "))
+	}
+
+	if !withCtx {
+		// No context, so span can't be started.
+		if len(results) > 0 {
+			fmt.Fprintf(w, "\treturn %s\n}\n", call)
+			return
+		}
+		fmt.Fprintf(w, "\t%s\n}\n", call)
+		return
+	}
+
+	g.usesTracing = true
+	fmt.Fprintf(w, "\t%s, span := tracing.StartSpan(%s, %q)\n", params[0], params[0], g.typeName+"."+m.Name())
+	// Span is ended even if the call panics. Recover records the panic and ends the span first, so the deferred
+	// end is ignored then.
+	if returnsErr {
+		fmt.Fprintf(w, "\tdefer func() { span.End(%s) }()\n", results[len(results)-1])
+	} else {
+		fmt.Fprintf(w, "\tdefer span.End(nil)\n")
+	}
+	fmt.Fprintf(w, "\tdefer tracing.Recover(span)\n")
+
+	var kvs []string
+	for _, p := range params[1:] {
+		if g.attr(m.Name(), p) {
+			kvs = append(kvs, fmt.Sprintf("%q, %s", p, p))
+		}
+	}
+	if len(kvs) > 0 {
+		fmt.Fprintf(w, "\tspan.SetAttributes(%s)\n", strings.Join(kvs, ", "))
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintf(w, "\t%s\n}\n", call)
+		return
+	}
+	fmt.Fprintf(w, "\treturn %s\n}\n", call)
+}
+
+func isContext(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
+
+func isStd(path string) bool {
+	return !strings.Contains(strings.Split(path, "/")[0], ".")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestGenerate(t *testing.T) {
+	g := generator{typeName: "Storage", attrs: []string{"key", "List.prefix"}}
+	src, err := g.generate(filepath.Join("testdata", "storage"))
+	testutil.Ok(t, err)
+
+	exp, err := os.ReadFile(filepath.Join("testdata", "storage", "storage_tracing.go.golden"))
+	testutil.Ok(t, err)
+	testutil.Equals(t, string(exp), string(src))
+
+	t.Run("generated code compiles", func(t *testing.T) {
+		testCompiles(t, filepath.Join("testdata", "storage", "storage.go"), src)
+	})
+}
+
+// testCompiles builds and vets the generated code together with the source package in the temporary module that
+// uses tracing package from this repository.
+func testCompiles(t *testing.T, srcFile string, generated []byte) {
+	t.Helper()
+
+	root, err := filepath.Abs(filepath.Join("..", ".."))
+	testutil.Ok(t, err)
+
+	dir := t.TempDir()
+	b, err := os.ReadFile(srcFile)
+	testutil.Ok(t, err)
+	testutil.Ok(t, os.WriteFile(filepath.Join(dir, filepath.Base(srcFile)), b, 0644))
+	testutil.Ok(t, os.WriteFile(filepath.Join(dir, "generated_tracing.go"), generated, 0644))
+
+	// Reuse checksums of the repository module, so dependencies are resolved from the module cache.
+	b, err = os.ReadFile(filepath.Join(root, "go.sum"))
+	testutil.Ok(t, err)
+	testutil.Ok(t, os.WriteFile(filepath.Join(dir, "go.sum"), b, 0644))
+	testutil.Ok(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(`module example.com/generated
+
+go 1.21
+
+require github.com/bwplotka/tracing-go v0.0.0
+
+replace github.com/bwplotka/tracing-go => `+root+"\n"), 0644))
+
+	for _, args := range [][]string{{"build", "./..."}, {"vet", "./..."}} {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+		out, err := cmd.CombinedOutput()
+		testutil.Ok(t, err, "go %v:\n%s", args, out)
+	}
+}
@@ -0,0 +1,18 @@
+module github.com/bwplotka/tracing-go/cmd/tracinggen
+
+go 1.22.0
+
+require (
+	github.com/efficientgo/tools/core v0.0.0-20220225185207-fe763185946b
+	github.com/pkg/errors v0.9.1
+	golang.org/x/tools v0.26.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.uber.org/goleak v1.1.10 // indirect
+	golang.org/x/lint v0.0.0-20190930215403-16217165b5de // indirect
+	golang.org/x/mod v0.21.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+)
@@ -0,0 +1,62 @@
+// Command tracinggen generates decorator of the Go interface that starts span for each method call using
+// github.com/bwplotka/tracing-go/tracing package.
+//
+// Each generated method with context.Context as the first parameter starts span named <Interface>.<Method> from that
+// context, sets attributes from the selected parameters and ends span with the returned error (if any). If the method
+// panics, the panic is recorded on the span (see tracing.Recover). Methods without context are passed through without span.
+//
+// Usage with go generate:
+//
+//	//go:generate go run github.com/bwplotka/tracing-go/cmd/tracinggen@latest -type=Storage -attrs=key,List.prefix
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	var (
+		typeName = flag.String("type", "", "Name of the interface to generate decorator for. Required.")
+		name     = flag.String("name", "", "Name of the generated decorator type. Defaults to Traced<type>.")
+		output   = flag.String("output", "", "Output file name. Defaults to <type>_tracing.go in the package directory.")
+		attrs    = flag.String("attrs", "", "Comma separated list of parameters to set as span attributes, either in <param> "+
+			"form (for all methods) or <Method>.<param> form.")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: tracinggen -type=<interface> [flags] [package directory]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	g := generator{typeName: *typeName, name: *name}
+	if *attrs != "" {
+		g.attrs = strings.Split(*attrs, ",")
+	}
+	src, err := g.generate(dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tracinggen:", err)
+		os.Exit(1)
+	}
+
+	out := *output
+	if out == "" {
+		out = filepath.Join(dir, strings.ToLower(*typeName)+"_tracing.go")
+	}
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "tracinggen:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+type Option func(*time.Duration)
+
+type Reader interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	List(ctx context.Context, prefix string, opts ...Option) ([]string, error)
+}
+
+type Storage interface {
+	Reader
+
+	Put(ctx context.Context, key string, r io.Reader) error
+	Delete(context.Context, string)
+	Size(ctx context.Context) int64
+	Close() error
+}
@@ -0,0 +1,11 @@
+// Command tracinglint checks span lifecycle correctness of code using github.com/bwplotka/tracing-go/tracing.
+//
+// It can be used as go vet tool:
+//
+//	go install github.com/bwplotka/tracing-go/cmd/tracinglint@latest
+//	go vet -vettool=$(which tracinglint) ./...
+package main
+
+import "golang.org/x/tools/go/analysis/unitchecker"
+
+func main() { unitchecker.Main(analyzer) }
@@ -0,0 +1,70 @@
+package a
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bwplotka/tracing-go/tracing"
+)
+
+func ok(ctx context.Context) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "ok")
+	defer func() { span.End(err) }()
+
+	return tracing.DoInSpan(ctx, "sub", func(ctx context.Context) error { return nil })
+}
+
+func okEndedOnAllPaths(ctx context.Context, fail bool) error {
+	_, span := tracing.StartSpan(ctx, "ok")
+	if fail {
+		err := errors.New("fail")
+		span.End(err)
+		return err
+	}
+	span.End(nil)
+	return nil
+}
+
+func notEnded(ctx context.Context) {
+	_, span := tracing.StartSpan(ctx, "not ended") // want `the span span is not ended on all paths`
+	span.SetAttributes("a", "b")
+} // want `this return statement may be reached without ending the span span started on line 29`
+
+func notEndedOnAllPaths(ctx context.Context, fail bool) error {
+	_, span := tracing.StartSpan(ctx, "not ended on all paths") // want `the span span is not ended on all paths`
+	if fail {
+		return errors.New("fail") // want `this return statement may be reached without ending the span span started on line 34`
+	}
+	span.End(nil)
+	return nil
+}
+
+func discardedSpan(tr *tracing.Tracer) {
+	_, _ = tr.StartSpan("discarded") // want `the span returned by Tracer.StartSpan should be ended, not discarded`
+}
+
+func deferredEndNil(ctx context.Context) (err error) {
+	_, span := tracing.StartSpan(ctx, "deferred")
+	defer span.End(nil) // want `deferred span.End\(nil\) argument is evaluated at defer time, so returned err error will not be recorded`
+
+	return errors.New("fail")
+}
+
+func deferredEndErr(ctx context.Context) (err error) {
+	_, span := tracing.StartSpan(ctx, "deferred")
+	defer span.End(err) // want `deferred span.End\(err\) argument is evaluated at defer time, so returned err error will not be recorded`
+
+	return errors.New("fail")
+}
+
+func discardedContext(ctx context.Context) error {
+	_, span := tracing.StartSpan(ctx, "parent") // want `the context returned by StartSpan is discarded, while ctx is used later, so child spans will attach to the wrong parent`
+	defer span.End(nil)
+
+	return tracing.DoInSpan(ctx, "child", func(ctx context.Context) error { return nil })
+}
+
+func nonConstantNameNotChecked(ctx context.Context, name string) error {
+	// Span names are checked only with -spannames flag.
+	return tracing.DoInSpan(ctx, "op "+name, func(ctx context.Context) error { return nil })
+}
@@ -0,0 +1,36 @@
+// Package tracing is a stub of github.com/bwplotka/tracing-go/tracing for tests.
+package tracing
+
+import "context"
+
+type Span interface {
+	End(err error)
+	SetAttributes(keyvals ...interface{})
+}
+
+type StartSpanOption func()
+
+type TracerStartSpanOption func()
+
+type Tracer struct{}
+
+func (tr *Tracer) StartSpan(spanName string, opts ...TracerStartSpanOption) (context.Context, Span) {
+	return nil, nil
+}
+
+func (tr *Tracer) DoInSpan(spanName string, f func(context.Context) error, opts ...TracerStartSpanOption) error {
+	return nil
+}
+
+func StartSpan(ctx context.Context, spanName string, opts ...StartSpanOption) (context.Context, Span) {
+	return nil, nil
+}
+
+func DoInSpan(ctx context.Context, spanName string, f func(context.Context) error, opts ...StartSpanOption) error {
+	return nil
+}
+
+func DoInSpanT[T any](ctx context.Context, spanName string, f func(context.Context) (T, error), opts ...StartSpanOption) (T, error) {
+	var t T
+	return t, nil
+}
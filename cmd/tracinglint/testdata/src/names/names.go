@@ -0,0 +1,29 @@
+package names
+
+import (
+	"context"
+
+	"github.com/bwplotka/tracing-go/tracing"
+)
+
+func nonConstantName(ctx context.Context, tr *tracing.Tracer, name string) {
+	const constName = "const"
+	_ = tracing.DoInSpan(ctx, constName, func(ctx context.Context) error { return nil })
+	_ = tracing.DoInSpan(ctx, "op "+name, func(ctx context.Context) error { return nil })               // want `span name is not constant`
+	_, _ = tracing.DoInSpanT(ctx, "op "+name, func(ctx context.Context) (int, error) { return 0, nil }) // want `span name is not constant`
+	n := name
+	_ = tr.DoInSpan(n, func(ctx context.Context) error { return nil }) // want `span name is not constant`
+}
+
+func wrapper(ctx context.Context, name string) error {
+	return tracing.DoInSpan(ctx, name, func(ctx context.Context) error { return nil })
+}
+
+type job struct {
+	spanName string
+	f        func(context.Context) error
+}
+
+func (j job) run(ctx context.Context) error {
+	return tracing.DoInSpan(ctx, j.spanName, j.f)
+}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/ctrlflow"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/cfg"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const doc = `check span lifecycle correctness of github.com/bwplotka/tracing-go/tracing
+
+The tracinglint analyzer reports:
+* spans returned by StartSpan that are discarded or not ended on all paths,
+* deferred span.End with argument evaluated at defer time in functions returning named error,
+* contexts returned by StartSpan that are discarded, while the parent context is still used,
+  so child spans attach to the wrong parent,
+* non-constant span names that risk high cardinality, if -spannames flag is set. Names forwarded from
+  parameters of the enclosing function or from struct fields (e.g. in instrumentation wrappers) are not
+  reported, since they are the responsibility of the caller.`
+
+var analyzer = &analysis.Analyzer{
+	Name: "tracinglint",
+	Doc:  doc,
+	Run:  run,
+	Requires: []*analysis.Analyzer{
+		inspect.Analyzer,
+		ctrlflow.Analyzer,
+	},
+}
+
+// checkSpanNames enables the check of non-constant span names, which is opt-in, since variable span names are often
+// legitimate (e.g. test or job names).
+var checkSpanNames bool
+
+func init() {
+	analyzer.Flags.BoolVar(&checkSpanNames, "spannames", false, "report non-constant span names, which risk high cardinality")
+}
+
+const tracingPkgPath = "github.com/bwplotka/tracing-go/tracing"
+
+// spanNameArgs maps tracing functions and Tracer methods creating spans to their span name argument index.
+var spanNameArgs = map[string]int{
+	"StartSpan":         1,
+	"DoInSpan":          1,
+	"DoInSpanT":         1,
+	"TracerDoInSpanT":   1,
+	"Go":                1,
+	"Tracer.StartSpan":  0,
+	"Tracer.DoInSpan":   0,
+	"Group.Go":          0,
+	"WorkerPool.Submit": 1,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if !imports(pass.Pkg, tracingPkgPath) && pass.Pkg.Path() != tracingPkgPath {
+		return nil, nil
+	}
+
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	ins.WithStack([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push || !checkSpanNames {
+			return true
+		}
+		call := n.(*ast.CallExpr)
+		idx, ok := spanNameArgs[tracingFuncName(pass.TypesInfo, call)]
+		if !ok || len(call.Args) <= idx {
+			return true
+		}
+		arg := call.Args[idx]
+		if tv, ok := pass.TypesInfo.Types[arg]; !ok || tv.Value != nil {
+			return true
+		}
+		// Span name passed as a parameter of the enclosing function or struct field (e.g. in instrumentation
+		// wrappers) is the responsibility of the caller.
+		if id, ok := arg.(*ast.Ident); ok && isParam(pass.TypesInfo, id, stack) {
+			return true
+		}
+		if sel, ok := arg.(*ast.SelectorExpr); ok && isField(pass.TypesInfo, sel) {
+			return true
+		}
+		pass.ReportRangef(arg, "span name is not constant, which risks high cardinality; use attributes for variable data")
+		return true
+	})
+	ins.Preorder([]ast.Node{(*ast.FuncLit)(nil), (*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		runFunc(pass, n)
+	})
+	return nil, nil
+}
+
+func imports(pkg *types.Package, path string) bool {
+	for _, imp := range pkg.Imports() {
+		if imp.Path() == path {
+			return true
+		}
+	}
+	return false
+}
+
+// isParam reports whether id refers to the parameter of any enclosing function in the stack.
+func isParam(info *types.Info, id *ast.Ident, stack []ast.Node) bool {
+	obj := info.Uses[id]
+	if obj == nil {
+		return false
+	}
+	for _, n := range stack {
+		var ft *ast.FuncType
+		switch f := n.(type) {
+		case *ast.FuncLit:
+			ft = f.Type
+		case *ast.FuncDecl:
+			ft = f.Type
+		default:
+			continue
+		}
+		// Function scope contains also top level variables of the function body, so check position too.
+		if obj.Parent() == info.Scopes[ft] && obj.Pos() >= ft.Pos() && obj.Pos() < ft.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// isField reports whether sel selects a struct field.
+func isField(info *types.Info, sel *ast.SelectorExpr) bool {
+	s, ok := info.Selections[sel]
+	return ok && s.Kind() == types.FieldVal
+}
+
+// tracingFuncName returns name of the tracing package function (e.g. "StartSpan") or method (e.g. "Tracer.StartSpan")
+// called by the given call or empty string if call is not calling the tracing package.
+func tracingFuncName(info *types.Info, call *ast.CallExpr) string {
+	fn, ok := typeutil.Callee(info, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != tracingPkgPath {
+		return ""
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return fn.Name()
+	}
+	t := recv.Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return ""
+	}
+	return named.Obj().Name() + "." + fn.Name()
+}
+
+type startSpan struct {
+	stmt ast.Stmt
+	call *ast.CallExpr
+	// ctx is the context ident passed to tracing.StartSpan, if any.
+	ctx *ast.Ident
+}
+
+func runFunc(pass *analysis.Pass, node ast.Node) {
+	var (
+		funcScope *types.Scope
+		sig       *types.Signature
+		body      *ast.BlockStmt
+	)
+	switch v := node.(type) {
+	case *ast.FuncLit:
+		funcScope = pass.TypesInfo.Scopes[v.Type]
+		sig, _ = pass.TypesInfo.Types[v.Type].Type.(*types.Signature)
+		body = v.Body
+	case *ast.FuncDecl:
+		funcScope = pass.TypesInfo.Scopes[v.Type]
+		if obj := pass.TypesInfo.Defs[v.Name]; obj != nil {
+			sig, _ = obj.Type().(*types.Signature)
+		}
+		body = v.Body
+	}
+	if sig == nil || body == nil {
+		return // Missing type information or function without body.
+	}
+	errResult := namedErrorResult(sig)
+
+	// Maps each span variable to its StartSpan statement.
+	spanVars := map[*types.Var]startSpan{}
+	inspectFunc(body, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.DeferStmt:
+			if errResult != nil {
+				checkDeferredEnd(pass, n, errResult)
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) != 2 || len(n.Rhs) != 1 {
+				return
+			}
+			call, ok := n.Rhs[0].(*ast.CallExpr)
+			if !ok {
+				return
+			}
+			name := tracingFuncName(pass.TypesInfo, call)
+			if name != "StartSpan" && name != "Tracer.StartSpan" {
+				return
+			}
+
+			ss := startSpan{stmt: n, call: call}
+			if name == "StartSpan" && len(call.Args) > 0 {
+				ss.ctx, _ = call.Args[0].(*ast.Ident)
+			}
+			checkDiscardedContext(pass, body, n, ss)
+
+			id, ok := n.Lhs[1].(*ast.Ident)
+			if !ok {
+				return
+			}
+			if id.Name == "_" {
+				pass.ReportRangef(id, "the span returned by %s should be ended, not discarded", name)
+				return
+			}
+			if v, ok := pass.TypesInfo.Uses[id].(*types.Var); ok {
+				// If the span variable is defined outside function scope, do not analyze it.
+				if funcScope.Contains(v.Pos()) {
+					spanVars[v] = ss
+				}
+			} else if v, ok := pass.TypesInfo.Defs[id].(*types.Var); ok {
+				spanVars[v] = ss
+			}
+		}
+	})
+	if len(spanVars) == 0 {
+		return
+	}
+
+	cfgs := pass.ResultOf[ctrlflow.Analyzer].(*ctrlflow.CFGs)
+	var g *cfg.CFG
+	switch node := node.(type) {
+	case *ast.FuncDecl:
+		g = cfgs.FuncDecl(node)
+	case *ast.FuncLit:
+		g = cfgs.FuncLit(node)
+	}
+
+	for v, ss := range spanVars {
+		ret := notEndedPath(pass, g, v, ss.stmt, sig)
+		if ret == nil {
+			continue
+		}
+
+		d := analysis.Diagnostic{
+			Pos:     ss.stmt.Pos(),
+			End:     ss.stmt.End(),
+			Message: fmt.Sprintf("the %s span is not ended on all paths", v.Name()),
+		}
+		if !endedAnywhere(pass, body, v) {
+			d.SuggestedFixes = []analysis.SuggestedFix{{
+				Message: "Add deferred End",
+				TextEdits: []analysis.TextEdit{{
+					Pos:     ss.stmt.End(),
+					End:     ss.stmt.End(),
+					NewText: []byte("\n" + indent(pass.Fset, ss.stmt) + deferredEnd(v.Name(), errResult)),
+				}},
+			}}
+		}
+		pass.Report(d)
+		lineno := pass.Fset.Position(ss.stmt.Pos()).Line
+		pass.ReportRangef(ret, "this return statement may be reached without ending the %s span started on line %d", v.Name(), lineno)
+	}
+}
+
+// inspectFunc calls f for every node of the function body, without straying into nested functions.
+func inspectFunc(body *ast.BlockStmt, f func(n ast.Node)) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		if n != nil {
+			f(n)
+		}
+		return true
+	})
+}
+
+// namedErrorResult returns the last named error result of the function or nil.
+func namedErrorResult(sig *types.Signature) *types.Var {
+	res := sig.Results()
+	if res.Len() == 0 {
+		return nil
+	}
+	last := res.At(res.Len() - 1)
+	if last.Name() == "" || last.Name() == "_" || !types.Identical(last.Type(), types.Universe.Lookup("error").Type()) {
+		return nil
+	}
+	return last
+}
+
+func deferredEnd(spanName string, errResult *types.Var) string {
+	if errResult == nil {
+		return fmt.Sprintf("defer %s.End(nil)", spanName)
+	}
+	return fmt.Sprintf("defer func() { %s.End(%s) }()", spanName, errResult.Name())
+}
+
+func indent(fset *token.FileSet, n ast.Node) string {
+	return strings.Repeat("\t", fset.Position(n.Pos()).Column-1)
+}
+
+// checkDeferredEnd reports `defer span.End(nil)` or `defer span.End(err)` in function returning named error,
+// as the argument is evaluated at the defer time, not at the function return.
+func checkDeferredEnd(pass *analysis.Pass, d *ast.DeferStmt, errResult *types.Var) {
+	sel, ok := d.Call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "End" || len(d.Call.Args) != 1 || !isSpan(pass.TypesInfo.TypeOf(sel.X)) {
+		return
+	}
+	arg, ok := d.Call.Args[0].(*ast.Ident)
+	if !ok {
+		return
+	}
+	if pass.TypesInfo.Uses[arg] != errResult && pass.TypesInfo.Uses[arg] != types.Universe.Lookup("nil") {
+		return
+	}
+	span := types.ExprString(sel.X)
+	pass.Report(analysis.Diagnostic{
+		Pos: d.Pos(),
+		End: d.End(),
+		Message: fmt.Sprintf("deferred %s.End(%s) argument is evaluated at defer time, so returned %s error will not be recorded",
+			span, arg.Name, errResult.Name()),
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message: fmt.Sprintf("End span with the returned %s error", errResult.Name()),
+			TextEdits: []analysis.TextEdit{{
+				Pos:     d.Pos(),
+				End:     d.End(),
+				NewText: []byte(deferredEnd(span, errResult)),
+			}},
+		}},
+	})
+}
+
+func isSpan(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == tracingPkgPath && named.Obj().Name() == "Span"
+}
+
+// checkDiscardedContext reports context returned by tracing.StartSpan that is discarded, while the parent context
+// is still used after the span start, so child spans would attach to the wrong parent.
+func checkDiscardedContext(pass *analysis.Pass, body *ast.BlockStmt, stmt *ast.AssignStmt, ss startSpan) {
+	id, ok := stmt.Lhs[0].(*ast.Ident)
+	if !ok || id.Name != "_" || ss.ctx == nil {
+		return
+	}
+	parent := pass.TypesInfo.Uses[ss.ctx]
+	if parent == nil {
+		return
+	}
+
+	usedAfter := false
+	inspectFunc(body, func(n ast.Node) {
+		if i, ok := n.(*ast.Ident); ok && i.Pos() > stmt.End() && pass.TypesInfo.Uses[i] == parent {
+			usedAfter = true
+		}
+	})
+	if !usedAfter {
+		return
+	}
+
+	pass.Report(analysis.Diagnostic{
+		Pos:     id.Pos(),
+		End:     id.End(),
+		Message: fmt.Sprintf("the context returned by StartSpan is discarded, while %s is used later, so child spans will attach to the wrong parent", ss.ctx.Name),
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message:   fmt.Sprintf("Assign returned context to %s", ss.ctx.Name),
+			TextEdits: []analysis.TextEdit{{Pos: id.Pos(), End: id.End(), NewText: []byte(ss.ctx.Name)}},
+		}},
+	})
+}
+
+// usesSpan reports whether stmts contain a "use" of span variable v, so calling End or any reference that might
+// end the span elsewhere (e.g. passing it to other function). Calls to other Span methods do not count as use.
+func usesSpan(pass *analysis.Pass, v *types.Var, stmts []ast.Node, vIsNamedResult bool) bool {
+	found := false
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				if id, ok := n.X.(*ast.Ident); ok && pass.TypesInfo.Uses[id] == v && n.Sel.Name != "End" {
+					return false
+				}
+			case *ast.Ident:
+				if pass.TypesInfo.Uses[n] == v {
+					found = true
+				}
+			case *ast.ReturnStmt:
+				// A naked return statement counts as a use of the named result variables.
+				if n.Results == nil && vIsNamedResult {
+					found = true
+				}
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// endedAnywhere reports whether End is called on span variable v anywhere in the function, including nested functions.
+func endedAnywhere(pass *analysis.Pass, body *ast.BlockStmt, v *types.Var) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok && sel.Sel.Name == "End" {
+			if id, ok := sel.X.(*ast.Ident); ok && pass.TypesInfo.Uses[id] == v {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// notEndedPath finds a path through the CFG, from stmt (which defines the span variable v) to a return statement,
+// that doesn't "use" v. If it finds one, it returns the return statement (which may be synthetic).
+// Adapted from golang.org/x/tools/go/analysis/passes/lostcancel.
+func notEndedPath(pass *analysis.Pass, g *cfg.CFG, v *types.Var, stmt ast.Node, sig *types.Signature) *ast.ReturnStmt {
+	vIsNamedResult := tupleContains(sig.Results(), v)
+
+	memo := map[*cfg.Block]bool{}
+	blockUses := func(b *cfg.Block) bool {
+		res, ok := memo[b]
+		if !ok {
+			res = usesSpan(pass, v, b.Nodes, vIsNamedResult)
+			memo[b] = res
+		}
+		return res
+	}
+
+	// Find the var's defining block in the CFG, plus the rest of the statements of that block.
+	var (
+		defblock *cfg.Block
+		rest     []ast.Node
+	)
+outer:
+	for _, b := range g.Blocks {
+		for i, n := range b.Nodes {
+			if n == stmt {
+				defblock = b
+				rest = b.Nodes[i+1:]
+				break outer
+			}
+		}
+	}
+	if defblock == nil {
+		// StartSpan in e.g. if or switch init statement, we can't say much.
+		return nil
+	}
+
+	if usesSpan(pass, v, rest, vIsNamedResult) {
+		return nil
+	}
+	if ret := defblock.Return(); ret != nil {
+		return ret
+	}
+
+	// Search the CFG depth-first for a path, from defblock to a return block, in which v is never "used".
+	seen := map[*cfg.Block]bool{}
+	var search func(blocks []*cfg.Block) *ast.ReturnStmt
+	search = func(blocks []*cfg.Block) *ast.ReturnStmt {
+		for _, b := range blocks {
+			if seen[b] {
+				continue
+			}
+			seen[b] = true
+
+			if blockUses(b) {
+				continue
+			}
+			if ret := b.Return(); ret != nil {
+				return ret
+			}
+			if ret := search(b.Succs); ret != nil {
+				return ret
+			}
+		}
+		return nil
+	}
+	return search(defblock.Succs)
+}
+
+func tupleContains(tuple *types.Tuple, v *types.Var) bool {
+	for i := 0; i < tuple.Len(); i++ {
+		if tuple.At(i) == v {
+			return true
+		}
+	}
+	return false
+}
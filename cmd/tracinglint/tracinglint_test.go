@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), analyzer, "a")
+}
+
+func TestAnalyzer_SpanNames(t *testing.T) {
+	if err := analyzer.Flags.Set("spannames", "true"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = analyzer.Flags.Set("spannames", "false") }()
+
+	analysistest.Run(t, analysistest.TestData(), analyzer, "names")
+}
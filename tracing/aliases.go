@@ -1,8 +1,24 @@
 package tracing
 
 import (
+	"go.opentelemetry.io/otel/propagation"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Sampler = sdktrace.Sampler
 type Exporter = sdktrace.SpanExporter
+type Propagator = propagation.TextMapPropagator
+type Carrier = propagation.TextMapCarrier
+type MapCarrier = propagation.MapCarrier
+type SpanKind = trace.SpanKind
+type IDGenerator = sdktrace.IDGenerator
+
+const (
+	SpanKindUnspecified = trace.SpanKindUnspecified
+	SpanKindInternal    = trace.SpanKindInternal
+	SpanKindServer      = trace.SpanKindServer
+	SpanKindClient      = trace.SpanKindClient
+	SpanKindProducer    = trace.SpanKindProducer
+	SpanKindConsumer    = trace.SpanKindConsumer
+)
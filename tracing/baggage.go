@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// W3C Baggage limits, see https://www.w3.org/TR/baggage/#limits.
+const (
+	maxBaggageMembers     = 180
+	maxBaggageMemberBytes = 4096
+	maxBaggageBytes       = 8192
+)
+
+// SetBaggage returns context with baggage member of the given key and value. Baggage is propagated to other
+// processes by instrumentation (e.g. tracinghttp) and Inject, if BaggagePropagator is configured (it is by default).
+// Error is returned (with unchanged context) if key or value is invalid or W3C Baggage limits would be exceeded.
+func SetBaggage(ctx context.Context, key, value string) (context.Context, error) {
+	m, err := baggage.NewMember(key, value)
+	if err != nil {
+		return ctx, errors.Wrap(err, "new baggage member")
+	}
+	if len(m.String()) > maxBaggageMemberBytes {
+		return ctx, errors.Errorf("baggage member %q exceeds %d bytes", key, maxBaggageMemberBytes)
+	}
+
+	b, err := baggage.FromContext(ctx).SetMember(m)
+	if err != nil {
+		return ctx, errors.Wrap(err, "set baggage member")
+	}
+	if b.Len() > maxBaggageMembers {
+		return ctx, errors.Errorf("baggage exceeds %d members", maxBaggageMembers)
+	}
+	if len(b.String()) > maxBaggageBytes {
+		return ctx, errors.Errorf("baggage exceeds %d bytes", maxBaggageBytes)
+	}
+	return baggage.ContextWithBaggage(ctx, b), nil
+}
+
+// Baggage returns all baggage members from the context as key value map.
+func Baggage(ctx context.Context) map[string]string {
+	members := baggage.FromContext(ctx).Members()
+	ret := make(map[string]string, len(members))
+	for _, m := range members {
+		ret[m.Key()] = m.Value()
+	}
+	return ret
+}
+
+// baggageAttrsProcessor copies selected baggage members from the parent context onto every new span as attributes.
+type baggageAttrsProcessor struct {
+	keys []string
+}
+
+func (p baggageAttrsProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	b := baggage.FromContext(parent)
+	for _, k := range p.keys {
+		if m := b.Member(k); m.Key() != "" {
+			s.SetAttributes(attribute.String(k, m.Value()))
+		}
+	}
+}
+
+func (baggageAttrsProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (baggageAttrsProcessor) Shutdown(context.Context) error   { return nil }
+func (baggageAttrsProcessor) ForceFlush(context.Context) error { return nil }
@@ -0,0 +1,49 @@
+package tracing_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestBaggage(t *testing.T) {
+	ctx, err := tracing.SetBaggage(context.Background(), "tenant", "team-a")
+	testutil.Ok(t, err)
+	ctx, err = tracing.SetBaggage(ctx, "priority", "high")
+	testutil.Ok(t, err)
+	testutil.Equals(t, map[string]string{"tenant": "team-a", "priority": "high"}, tracing.Baggage(ctx))
+
+	_, err = tracing.SetBaggage(ctx, "invalid key", "a")
+	testutil.NotOk(t, err)
+	_, err = tracing.SetBaggage(ctx, "key", strings.Repeat("a", 4097))
+	testutil.NotOk(t, err)
+
+	tooMany := context.Background()
+	for i := 0; i < 180; i++ {
+		tooMany, err = tracing.SetBaggage(tooMany, fmt.Sprintf("k%d", i), "v")
+		testutil.Ok(t, err)
+	}
+	_, err = tracing.SetBaggage(tooMany, "k180", "v")
+	testutil.NotOk(t, err)
+
+	// Baggage has to be propagated and copied to spans as attributes in both processes.
+	tr, rec := tracingtest.NewTracer(t, tracing.WithBaggageAttributes("tenant"))
+	carrier := tracing.MapCarrier{}
+	ctx, root := tr.StartSpan("root", tracing.WithTracerStartSpanContext(ctx))
+	tracing.Inject(ctx, carrier)
+	root.End(nil)
+
+	_, remote := tr.StartSpan("remote", tracing.WithTracerStartSpanContext(tr.Extract(context.Background(), carrier)))
+	remote.End(nil)
+
+	spans := rec.Spans()
+	testutil.Equals(t, 2, len(spans))
+	for _, s := range spans {
+		testutil.Equals(t, map[string]string{"tenant": "team-a"}, s.Attributes)
+	}
+}
@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Clock provides current time for span start, end and event timestamps.
+type Clock interface {
+	Now() time.Time
+}
+
+// WithClock sets clock used for span timestamps. Useful for deterministic output in tests and examples.
+// NOTE: Context deadlines and durations (e.g. "queue.wait") are still measured with the real time.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithIDGenerator sets generator of trace and span IDs, e.g. NewSequentialIDGenerator. By default, IDs are random.
+func WithIDGenerator(g IDGenerator) Option {
+	return func(o *options) {
+		o.idGenerator = g
+	}
+}
+
+type sequentialIDGenerator struct {
+	mu      sync.Mutex
+	traceID uint64
+	spanID  uint64
+}
+
+// NewSequentialIDGenerator returns IDGenerator returning sequential trace and span IDs, starting from 1.
+func NewSequentialIDGenerator() IDGenerator {
+	return &sequentialIDGenerator{}
+}
+
+func (g *sequentialIDGenerator) NewIDs(context.Context) (trace.TraceID, trace.SpanID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.traceID++
+	g.spanID++
+	tid := trace.TraceID{}
+	binary.BigEndian.PutUint64(tid[8:], g.traceID)
+	sid := trace.SpanID{}
+	binary.BigEndian.PutUint64(sid[:], g.spanID)
+	return tid, sid
+}
+
+func (g *sequentialIDGenerator) NewSpanID(context.Context, trace.TraceID) trace.SpanID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.spanID++
+	sid := trace.SpanID{}
+	binary.BigEndian.PutUint64(sid[:], g.spanID)
+	return sid
+}
+
+type seededIDGenerator struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// NewSeededIDGenerator returns IDGenerator returning random trace and span IDs from the sequence determined by
+// the given seed.
+func NewSeededIDGenerator(seed int64) IDGenerator {
+	return &seededIDGenerator{rnd: rand.New(rand.NewSource(seed))}
+}
+
+func (g *seededIDGenerator) NewIDs(context.Context) (trace.TraceID, trace.SpanID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tid := trace.TraceID{}
+	_, _ = g.rnd.Read(tid[:])
+	sid := trace.SpanID{}
+	_, _ = g.rnd.Read(sid[:])
+	return tid, sid
+}
+
+func (g *seededIDGenerator) NewSpanID(context.Context, trace.TraceID) trace.SpanID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	sid := trace.SpanID{}
+	_, _ = g.rnd.Read(sid[:])
+	return sid
+}
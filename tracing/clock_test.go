@@ -0,0 +1,47 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestWithClockAndIDGenerator(t *testing.T) {
+	run := func(idGen tracing.IDGenerator) string {
+		b := &bytes.Buffer{}
+		tr, closeFn, err := tracing.NewTracer(
+			tracing.NewWriterExporter(b),
+			tracing.WithServiceName("app"),
+			tracing.WithSynchronousExport(),
+			tracing.WithClock(tracingtest.NewStepClock(time.Unix(0, 0).UTC(), time.Second)),
+			tracing.WithIDGenerator(idGen),
+		)
+		testutil.Ok(t, err)
+
+		testutil.Ok(t, tr.DoInSpan("root", func(ctx context.Context) error {
+			tracing.GetSpan(ctx).AddEvent("event")
+			return tracing.DoInSpan(ctx, "child", func(context.Context) error { return nil })
+		}))
+		testutil.Ok(t, closeFn())
+		return b.String()
+	}
+
+	out := run(tracing.NewSequentialIDGenerator())
+	testutil.Equals(t, out, run(tracing.NewSequentialIDGenerator()))
+	testutil.Assert(t, strings.Contains(out, `"TraceID":"00000000000000000000000000000001"`), out)
+	testutil.Assert(t, strings.Contains(out, `"SpanID":"0000000000000002"`), out)
+	// Root starts at 0s, event at 1s, child from 2s to 3s, root ends at 4s.
+	testutil.Assert(t, strings.Contains(out, `"StartTime":"1970-01-01T00:00:02Z","EndTime":"1970-01-01T00:00:03Z"`), out)
+	testutil.Assert(t, strings.Contains(out, `"Time":"1970-01-01T00:00:01Z"`), out)
+	testutil.Assert(t, strings.Contains(out, `"StartTime":"1970-01-01T00:00:00Z","EndTime":"1970-01-01T00:00:04Z"`), out)
+
+	seeded := run(tracing.NewSeededIDGenerator(42))
+	testutil.Equals(t, seeded, run(tracing.NewSeededIDGenerator(42)))
+	testutil.Assert(t, seeded != run(tracing.NewSeededIDGenerator(43)))
+}
@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"context"
+	"time"
+)
+
+// WithContextTracking makes all spans started by this tracer record the time remaining until the context deadline as
+// "ctx.deadline.remaining" attribute (if context has a deadline) and "context done" event with the cause (e.g.
+// "context deadline exceeded"), if the context is cancelled or its deadline expires before the span is ended.
+func WithContextTracking() Option {
+	return func(o *options) {
+		o.trackContext = true
+	}
+}
+
+// trackContext records context deadline on the span and starts watching context for cancellation.
+func (s *span) trackContext(ctx context.Context) {
+	if deadline, ok := ctx.Deadline(); ok {
+		s.SetAttributes("ctx.deadline.remaining", time.Until(deadline))
+	}
+	if ctx.Done() == nil {
+		// Context can't be cancelled.
+		return
+	}
+
+	recorded := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		s.AddEvent("context done", "cause", context.Cause(ctx))
+		close(recorded)
+	})
+	s.stopTracking = func() {
+		if !stop() {
+			// Context is done, make sure event is recorded before span ends.
+			<-recorded
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DebugAttributeKey is the attribute key set on all spans that were forced to be sampled by ContextWithDebug.
+const DebugAttributeKey = "debug"
+
+type debugKey struct{}
+
+// ContextWithDebug returns context that forces sampling of all spans created from it (and its children),
+// regardless of the sampler configured in the Tracer. Spans are marked with DebugAttributeKey attribute with given
+// debugID as a value (e.g. "true" or Jaeger debug ID), so they can be easily found in the tracing backend.
+func ContextWithDebug(ctx context.Context, debugID string) context.Context {
+	return context.WithValue(ctx, debugKey{}, debugID)
+}
+
+// DebugIDFromContext returns debug ID set by ContextWithDebug, so debug mode can be propagated to other services
+// (e.g. by tracinghttp.Tripperware).
+func DebugIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(debugKey{}).(string)
+	return id, ok
+}
+
+// debugSampler wraps sampler and always samples spans created from context with ContextWithDebug.
+type debugSampler struct {
+	Sampler
+}
+
+func (s debugSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	id, ok := DebugIDFromContext(p.ParentContext)
+	if !ok {
+		return s.Sampler.ShouldSample(p)
+	}
+	return sdktrace.SamplingResult{
+		Decision:   sdktrace.RecordAndSample,
+		Attributes: []attribute.KeyValue{attribute.String(DebugAttributeKey, id)},
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+func (s debugSampler) Description() string {
+	return "Debug{" + s.Sampler.Description() + "}"
+}
@@ -0,0 +1,131 @@
+package otlp
+
+import (
+	"context"
+
+	"github.com/efficientgo/tools/core/pkg/merrors"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+)
+
+// WithHeadersFunc sets the function returning headers sent with each export request (including retries), in addition
+// to the WithHeaders ones. It's useful for short-lived auth tokens or tenant headers, e.g. "X-Scope-OrgID". Tenant
+// of the request set by WithTenantRouting is available via TenantFromContext.
+func WithHeadersFunc(f func(ctx context.Context) (map[string]string, error)) Option {
+	return Option{dialOpts: []grpc.DialOption{grpc.WithPerRPCCredentials(headersFunc(f))}}
+}
+
+// headersFunc implements credentials.PerRPCCredentials.
+type headersFunc func(ctx context.Context) (map[string]string, error)
+
+func (f headersFunc) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	h, err := f(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get OTLP request headers")
+	}
+	return h, nil
+}
+
+// RequireTransportSecurity returns false, so headers can be used with WithInsecure.
+func (headersFunc) RequireTransportSecurity() bool { return false }
+
+type tenantKey struct{}
+
+// TenantFromContext returns tenant of the export request set by WithTenantRouting. It's empty for the spans without
+// the tenant attribute or when tenant routing is not used.
+func TenantFromContext(ctx context.Context) string {
+	t, _ := ctx.Value(tenantKey{}).(string)
+	return t
+}
+
+// WithTenantRouting splits each batch of spans into separate export requests per tenant. Tenant is the value of the
+// span attribute with the given key or, if the span does not have it, the resource attribute with this key. If header
+// is not empty, the tenant is sent in the header with that name, e.g. "X-Scope-OrgID". Spans without tenant are sent
+// without the header. Use TenantFromContext in WithHeadersFunc for per tenant headers, e.g. auth tokens.
+func WithTenantRouting(attrKey, header string) Option {
+	o := Option{wrapClient: func(c otlptrace.Client) otlptrace.Client {
+		return &tenantClient{Client: c, key: attrKey}
+	}}
+	if header == "" {
+		return o
+	}
+	o.dialOpts = WithHeadersFunc(func(ctx context.Context) (map[string]string, error) {
+		t := TenantFromContext(ctx)
+		if t == "" {
+			return nil, nil
+		}
+		return map[string]string{header: t}, nil
+	}).dialOpts
+	return o
+}
+
+// tenantClient is the otlptrace.Client that uploads spans in separate requests per tenant.
+type tenantClient struct {
+	otlptrace.Client
+
+	key string
+}
+
+// batch is the part of the uploaded spans, which belongs to the same tenant.
+type batch struct {
+	resourceSpans []*tracepb.ResourceSpans
+	// Last resource and scope spans, so consecutive spans with the same resource and scope are grouped together.
+	rs *tracepb.ResourceSpans
+	ss *tracepb.ScopeSpans
+}
+
+func (c *tenantClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	var (
+		tenants []string
+		batches = map[string]*batch{}
+	)
+	for _, rs := range protoSpans {
+		resTenant, _ := attrValue(rs.GetResource().GetAttributes(), c.key)
+		for _, ss := range rs.GetScopeSpans() {
+			for _, s := range ss.GetSpans() {
+				tenant, ok := attrValue(s.GetAttributes(), c.key)
+				if !ok {
+					tenant = resTenant
+				}
+
+				b, ok := batches[tenant]
+				if !ok {
+					b = &batch{}
+					batches[tenant] = b
+					tenants = append(tenants, tenant)
+				}
+				if b.rs == nil || b.rs.Resource != rs.Resource {
+					b.rs = &tracepb.ResourceSpans{Resource: rs.Resource, SchemaUrl: rs.SchemaUrl}
+					b.resourceSpans = append(b.resourceSpans, b.rs)
+					b.ss = nil
+				}
+				if b.ss == nil || b.ss.Scope != ss.Scope {
+					b.ss = &tracepb.ScopeSpans{Scope: ss.Scope, SchemaUrl: ss.SchemaUrl}
+					b.rs.ScopeSpans = append(b.rs.ScopeSpans, b.ss)
+				}
+				b.ss.Spans = append(b.ss.Spans, s)
+			}
+		}
+	}
+
+	// Upload all tenants, even if some fail, so one tenant can't block the others.
+	errs := merrors.New()
+	for _, t := range tenants {
+		if err := c.Client.UploadTraces(context.WithValue(ctx, tenantKey{}, t), batches[t].resourceSpans); err != nil {
+			errs.Add(errors.Wrapf(err, "upload spans of tenant %q", t))
+		}
+	}
+	return errs.Err()
+}
+
+func attrValue(attrs []*commonpb.KeyValue, key string) (string, bool) {
+	for _, a := range attrs {
+		if a.GetKey() == key {
+			return a.GetValue().GetStringValue(), true
+		}
+	}
+	return "", false
+}
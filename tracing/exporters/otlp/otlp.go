@@ -2,6 +2,7 @@ package otlp
 
 import (
 	"context"
+	"time"
 
 	"github.com/bwplotka/tracing-go/tracing"
 	"github.com/pkg/errors"
@@ -14,19 +15,47 @@ import (
 // Option represents gRPC OTLP exporter option.
 type Option struct {
 	otelOpt otlptracegrpc.Option
+	// check is an optional validation done when exporter is built.
+	check func() error
+	// dialOpts are gRPC dial options. They are merged, since otlptracegrpc.WithDialOption overrides the previous ones.
+	dialOpts []grpc.DialOption
+	// wrapClient optionally wraps the gRPC client, e.g. to split uploads.
+	wrapClient func(otlptrace.Client) otlptrace.Client
 }
 
 // Exporter sets the gRPC OTLP exporter builder that builds exporter for spans that can be used in tracing.WithExporter().
 // Endpoint is in form of host:port.
 func Exporter(endpoint string, opts ...Option) tracing.ExporterBuilder {
 	oopts := make([]otlptracegrpc.Option, 0, len(opts))
+	var dialOpts []grpc.DialOption
 	for _, o := range opts {
+		dialOpts = append(dialOpts, o.dialOpts...)
+		if o.otelOpt == nil {
+			continue
+		}
 		oopts = append(oopts, o.otelOpt)
 	}
+	if len(dialOpts) > 0 {
+		oopts = append(oopts, otlptracegrpc.WithDialOption(dialOpts...))
+	}
 	oopts = append(oopts, otlptracegrpc.WithEndpoint(endpoint))
 
 	return func() (tracing.Exporter, error) {
-		e, err := otlptrace.New(context.TODO(), otlptracegrpc.NewClient(oopts...))
+		for _, o := range opts {
+			if o.check == nil {
+				continue
+			}
+			if err := o.check(); err != nil {
+				return nil, errors.Wrap(err, "OTLP exporter creation")
+			}
+		}
+		c := otlptracegrpc.NewClient(oopts...)
+		for _, o := range opts {
+			if o.wrapClient != nil {
+				c = o.wrapClient(c)
+			}
+		}
+		e, err := otlptrace.New(context.TODO(), c)
 		if err != nil {
 			return nil, errors.Wrap(err, "OTLP exporter creation")
 		}
@@ -45,7 +74,7 @@ func WithInsecure() Option {
 // with some other configuration the GRPC specified via the collector the ones here will
 // take preference since they are set last.
 func WithDialOption(opts ...grpc.DialOption) Option {
-	return Option{otelOpt: otlptracegrpc.WithDialOption(opts...)}
+	return Option{dialOpts: opts}
 }
 
 // WithHeaders will send the provided headers with gRPC requests/
@@ -61,3 +90,52 @@ func WithHeaders(headers map[string]string) Option {
 func WithTLSCredentials(creds credentials.TransportCredentials) Option {
 	return Option{otelOpt: otlptracegrpc.WithTLSCredentials(creds)}
 }
+
+// WithCompressor sets the compressor for the gRPC requests, e.g. "gzip". By default, requests are not compressed.
+func WithCompressor(compressor string) Option {
+	return Option{otelOpt: otlptracegrpc.WithCompressor(compressor)}
+}
+
+// WithTimeout sets the maximum time for each export request (including retries). Defaults to 10s.
+func WithTimeout(d time.Duration) Option {
+	return Option{otelOpt: otlptracegrpc.WithTimeout(d)}
+}
+
+// RetryConfig defines retry behaviour of the failed export requests.
+type RetryConfig struct {
+	// Enabled enables retries.
+	Enabled bool
+	// InitialInterval is the time to wait after the first failure before retrying.
+	InitialInterval time.Duration
+	// MaxInterval is the upper bound on backoff interval.
+	MaxInterval time.Duration
+	// MaxElapsedTime is the maximum time spent trying to send a request, after which it's dropped.
+	MaxElapsedTime time.Duration
+}
+
+// WithRetry sets the retry policy for transient errors of export requests. By default, retries are enabled with
+// 5s initial interval, 30s max interval and 1m max elapsed time.
+func WithRetry(cfg RetryConfig) Option {
+	return Option{otelOpt: otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{
+		Enabled:         cfg.Enabled,
+		InitialInterval: cfg.InitialInterval,
+		MaxInterval:     cfg.MaxInterval,
+		MaxElapsedTime:  cfg.MaxElapsedTime,
+	})}
+}
+
+// WithReconnectionPeriod sets the minimum amount of time between connection attempts to the target endpoint.
+func WithReconnectionPeriod(d time.Duration) Option {
+	return Option{otelOpt: otlptracegrpc.WithReconnectionPeriod(d)}
+}
+
+// WithTLSFiles makes the connection use TLS with CA certificate, client certificate and key read from the given files.
+// If caFile is empty, system CA certificates are used. If certFile and keyFile are empty, client certificate is not
+// used. Files are reloaded on new connections when they change, so they can be rotated without restart.
+func WithTLSFiles(caFile, certFile, keyFile string) Option {
+	r := &reloadingTLS{caFile: caFile, certFile: certFile, keyFile: keyFile}
+	return Option{
+		otelOpt: otlptracegrpc.WithTLSCredentials(r),
+		check:   r.reload,
+	}
+}
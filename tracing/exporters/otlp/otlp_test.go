@@ -0,0 +1,257 @@
+package otlp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest/otlpreceiver"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// writeCert writes self-signed certificate for the given IP (usable by both server and client) and its key to PEM
+// files in the given directory.
+func writeCert(t *testing.T, dir string, serial int64, ip string) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testutil.Ok(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP(ip)},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	testutil.Ok(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	testutil.Ok(t, err)
+
+	testutil.Ok(t, os.WriteFile(filepath.Join(dir, "cert.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	testutil.Ok(t, os.WriteFile(filepath.Join(dir, "key.pem"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	cert, err := x509.ParseCertificate(der)
+	testutil.Ok(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func runApp(t *testing.T, exporter tracing.ExporterBuilder) {
+	t.Helper()
+
+	tr, closeFn, err := tracing.NewTracer(exporter)
+	testutil.Ok(t, err)
+	_ = tr.DoInSpan("root", func(context.Context) error { return nil })
+	testutil.Ok(t, closeFn())
+}
+
+func TestExporter_CompressionAndRetry(t *testing.T) {
+	failures := 2
+	r, err := otlpreceiver.Start(otlpreceiver.WithExportError(func(otlpreceiver.Request) error {
+		if failures > 0 {
+			failures--
+			return status.Error(codes.Unavailable, "try later")
+		}
+		return nil
+	}))
+	testutil.Ok(t, err)
+	defer r.Close()
+
+	runApp(t, Exporter(
+		r.Endpoint(),
+		WithInsecure(),
+		WithCompressor("gzip"),
+		WithTimeout(10*time.Second),
+		WithReconnectionPeriod(100*time.Millisecond),
+		WithRetry(RetryConfig{Enabled: true, InitialInterval: 10 * time.Millisecond, MaxInterval: 50 * time.Millisecond, MaxElapsedTime: 5 * time.Second}),
+	))
+
+	testutil.Equals(t, 0, failures)
+	reqs := r.Requests()
+	testutil.Equals(t, 1, len(reqs))
+	testutil.Equals(t, "gzip", reqs[0].Compression)
+	testutil.Equals(t, "root", reqs[0].Spans[0].Name)
+}
+
+func TestExporter_TLSFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	_, err := Exporter("127.0.0.1:1", WithTLSFiles(filepath.Join(dir, "missing.pem"), "", ""))()
+	testutil.NotOk(t, err)
+
+	for i := int64(1); i <= 2; i++ {
+		// Rotate the certificate, which is used as CA, client and server certificate.
+		cert, pool := writeCert(t, dir, i, "127.0.0.1")
+		// Make sure modification time changes, even on file systems with coarse time resolution.
+		mtime := time.Now().Add(time.Duration(i) * time.Minute)
+		testutil.Ok(t, os.Chtimes(certFile, mtime, mtime))
+		testutil.Ok(t, os.Chtimes(keyFile, mtime, mtime))
+
+		r, err := otlpreceiver.Start(otlpreceiver.WithTLS(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    pool,
+		}))
+		testutil.Ok(t, err)
+
+		runApp(t, Exporter(r.Endpoint(), WithTLSFiles(certFile, certFile, keyFile), WithRetry(RetryConfig{Enabled: false})))
+		testutil.Equals(t, 1, len(r.Spans()))
+		r.Close()
+	}
+}
+
+func TestReloadingTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	writeCert(t, dir, 1, "127.0.0.1")
+	r := &reloadingTLS{caFile: certFile, certFile: certFile, keyFile: keyFile}
+	testutil.Ok(t, r.reload())
+	first := r.cert
+
+	// Not changed files are not reloaded.
+	testutil.Ok(t, r.reload())
+	testutil.Assert(t, first == r.cert)
+
+	writeCert(t, dir, 2, "127.0.0.1")
+	mtime := time.Now().Add(time.Minute)
+	testutil.Ok(t, os.Chtimes(certFile, mtime, mtime))
+	testutil.Ok(t, r.reload())
+	testutil.Assert(t, first != r.cert)
+
+	testutil.NotOk(t, (&reloadingTLS{caFile: certFile, certFile: certFile}).reload())
+}
+
+func TestExporter_TenantRouting(t *testing.T) {
+	r, err := otlpreceiver.Start()
+	testutil.Ok(t, err)
+	defer r.Close()
+
+	tr, closeFn, err := tracing.NewTracer(Exporter(
+		r.Endpoint(),
+		WithInsecure(),
+		WithTenantRouting("tenant", "X-Scope-OrgID"),
+		WithHeadersFunc(func(ctx context.Context) (map[string]string, error) {
+			return map[string]string{"authorization": "token-" + TenantFromContext(ctx)}, nil
+		}),
+	), tracing.WithSynchronousExport())
+	testutil.Ok(t, err)
+
+	ctx, root := tr.StartSpan("root")
+	for _, tenant := range []string{"team-a", "team-b", "team-a"} {
+		_, s := tracing.StartSpan(ctx, "op")
+		s.SetAttributes("tenant", tenant)
+		s.End(nil)
+	}
+	root.End(nil)
+	testutil.Ok(t, closeFn())
+
+	got := map[string]int{}
+	for _, req := range r.Requests() {
+		testutil.Equals(t, 1, len(req.Spans))
+		tenant := req.Metadata.Get("x-scope-orgid")
+		if req.Spans[0].Name == "root" {
+			testutil.Equals(t, 0, len(tenant))
+			testutil.Equals(t, []string{"token-"}, req.Metadata.Get("authorization"))
+			continue
+		}
+		testutil.Equals(t, 1, len(tenant))
+		testutil.Equals(t, []string{"token-" + tenant[0]}, req.Metadata.Get("authorization"))
+		got[tenant[0]]++
+	}
+	testutil.Equals(t, map[string]int{"team-a": 2, "team-b": 1}, got)
+}
+
+func TestExporter_TenantRoutingByResource(t *testing.T) {
+	r, err := otlpreceiver.Start()
+	testutil.Ok(t, err)
+	defer r.Close()
+
+	// Resource attribute is used for spans without the tenant attribute.
+	tr, closeFn, err := tracing.NewTracer(Exporter(
+		r.Endpoint(),
+		WithInsecure(),
+		WithTenantRouting("service.name", "X-Scope-OrgID"),
+	), tracing.WithServiceName("team-c"))
+	testutil.Ok(t, err)
+
+	ctx, root := tr.StartSpan("root")
+	_, s := tracing.StartSpan(ctx, "op")
+	s.SetAttributes("service.name", "team-d")
+	s.End(nil)
+	root.End(nil)
+	testutil.Ok(t, closeFn())
+
+	got := map[string][]string{}
+	for _, req := range r.Requests() {
+		tenant := req.Metadata.Get("x-scope-orgid")[0]
+		for _, sp := range req.Spans {
+			got[tenant] = append(got[tenant], sp.Name)
+		}
+	}
+	testutil.Equals(t, map[string][]string{"team-c": {"root"}, "team-d": {"op"}}, got)
+}
+
+func TestReloadingTLS_VerifiesServerAddress(t *testing.T) {
+	for _, tcase := range []struct {
+		name    string
+		certIP  string
+		caFile  bool
+		success bool
+	}{
+		{name: "valid", certIP: "127.0.0.1", caFile: true, success: true},
+		{name: "wrong IP", certIP: "127.0.0.2", caFile: true},
+		{name: "not trusted by system CA", certIP: "127.0.0.1"},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cert, _ := writeCert(t, dir, 1, tcase.certIP)
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			testutil.Ok(t, err)
+			defer ln.Close()
+			go func() {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				_ = tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}}).Handshake()
+			}()
+
+			r := &reloadingTLS{}
+			if tcase.caFile {
+				r.caFile = filepath.Join(dir, "cert.pem")
+			}
+			conn, err := net.Dial("tcp", ln.Addr().String())
+			testutil.Ok(t, err)
+			defer conn.Close()
+
+			_, _, err = r.ClientHandshake(context.Background(), ln.Addr().String(), conn)
+			if !tcase.success {
+				testutil.NotOk(t, err)
+				return
+			}
+			testutil.Ok(t, err)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package otlp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+)
+
+// reloadingTLS are gRPC transport credentials with certificates read from files, which are reloaded when files
+// change. Each handshake uses TLS config with the current certificates, so standard verification (including the
+// server name or IP from the dialed authority) is always done.
+type reloadingTLS struct {
+	caFile, certFile, keyFile string
+
+	mu         sync.Mutex
+	modTime    map[string]time.Time
+	caPool     *x509.CertPool
+	cert       *tls.Certificate
+	serverName string
+}
+
+var _ credentials.TransportCredentials = &reloadingTLS{}
+
+// config returns TLS config with the current certificates.
+func (r *reloadingTLS) config() (*tls.Config, error) {
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Nil RootCAs means system CA certificates.
+	cfg := &tls.Config{RootCAs: r.caPool, ServerName: r.serverName}
+	if r.cert != nil {
+		cfg.Certificates = []tls.Certificate{*r.cert}
+	}
+	return cfg, nil
+}
+
+func (r *reloadingTLS) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	cfg, err := r.config()
+	if err != nil {
+		return nil, nil, err
+	}
+	// Server name is set from the authority, if not overridden.
+	return credentials.NewTLS(cfg).ClientHandshake(ctx, authority, conn)
+}
+
+func (r *reloadingTLS) ServerHandshake(net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("reloading TLS credentials are client only")
+}
+
+func (r *reloadingTLS) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: "tls", SecurityVersion: "1.2"}
+}
+
+// Clone returns the same credentials, since certificates are shared anyway.
+func (r *reloadingTLS) Clone() credentials.TransportCredentials { return r }
+
+func (r *reloadingTLS) OverrideServerName(serverName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.serverName = serverName
+	return nil
+}
+
+// changed returns true if any of the given files changed since the last check.
+func (r *reloadingTLS) changed(files ...string) (bool, error) {
+	changed := false
+	for _, f := range files {
+		if f == "" {
+			continue
+		}
+		fi, err := os.Stat(f)
+		if err != nil {
+			return false, errors.Wrapf(err, "stat %v", f)
+		}
+		if !fi.ModTime().Equal(r.modTime[f]) {
+			r.modTime[f] = fi.ModTime()
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// reload reads certificates from files, if they changed since the last reload.
+func (r *reloadingTLS) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.modTime == nil {
+		r.modTime = map[string]time.Time{}
+	}
+	if changed, err := r.changed(r.caFile); err != nil {
+		return err
+	} else if changed {
+		b, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return errors.Wrapf(err, "read CA file %v", r.caFile)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return errors.Errorf("no certificates found in CA file %v", r.caFile)
+		}
+		r.caPool = pool
+	}
+
+	if (r.certFile == "") != (r.keyFile == "") {
+		return errors.New("both client certificate and key files have to be specified")
+	}
+	if changed, err := r.changed(r.certFile, r.keyFile); err != nil {
+		return err
+	} else if changed {
+		cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return errors.Wrapf(err, "load client certificate %v and key %v", r.certFile, r.keyFile)
+		}
+		r.cert = &cert
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package otlphttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultURLPath is the default URL path of OTLP/HTTP traces endpoint.
+const DefaultURLPath = "/v1/traces"
+
+// Option represents HTTP OTLP exporter option.
+type Option struct {
+	otelOpt otlptracehttp.Option
+	apply   func(*client)
+}
+
+// Exporter sets the HTTP OTLP exporter builder that builds exporter for spans that can be used in tracing.WithExporter().
+// Endpoint is in form of host:port. By default, spans are sent with HTTPS in protobuf encoding to DefaultURLPath.
+//
+// Spans in protobuf encoding are sent using OpenTelemetry otlptracehttp client. JSON encoding and custom HTTP client
+// are not supported by it, so with WithJSONEncoding or WithHTTPClient, spans are sent by the client of this package.
+func Exporter(endpoint string, opts ...Option) tracing.ExporterBuilder {
+	return func() (tracing.Exporter, error) {
+		c := &client{endpoint: endpoint, urlPath: DefaultURLPath, scheme: "https"}
+		oopts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+		for _, o := range opts {
+			if o.otelOpt != nil {
+				oopts = append(oopts, o.otelOpt)
+			}
+			if o.apply != nil {
+				o.apply(c)
+			}
+		}
+
+		var oc otlptrace.Client
+		switch {
+		case !c.json && c.httpClient == nil:
+			oc = otlptracehttp.NewClient(oopts...)
+		case c.tlsConfig != nil && c.httpClient != nil && c.httpClient.Transport != nil:
+			return nil, errors.New("OTLP HTTP exporter creation: TLS config can't be used together with custom http.Client transport")
+		default:
+			if c.httpClient == nil {
+				c.httpClient = &http.Client{}
+			}
+			if c.tlsConfig != nil {
+				t := http.DefaultTransport.(*http.Transport).Clone()
+				t.TLSClientConfig = c.tlsConfig
+				c.httpClient.Transport = t
+			}
+			oc = c
+		}
+
+		e, err := otlptrace.New(context.TODO(), oc)
+		if err != nil {
+			return nil, errors.Wrap(err, "OTLP HTTP exporter creation")
+		}
+		return e, nil
+	}
+}
+
+// WithInsecure disables client transport security, so plain HTTP is used. Note, by default, HTTPS is used.
+func WithInsecure() Option {
+	return Option{otelOpt: otlptracehttp.WithInsecure(), apply: func(c *client) { c.scheme = "http" }}
+}
+
+// WithTLSClientConfig sets TLS configuration used for HTTPS connections.
+// It can't be used together with WithHTTPClient with custom transport.
+func WithTLSClientConfig(cfg *tls.Config) Option {
+	return Option{otelOpt: otlptracehttp.WithTLSClientConfig(cfg), apply: func(c *client) { c.tlsConfig = cfg }}
+}
+
+// WithHTTPClient sets HTTP client used to send spans, e.g. with custom proxy or timeouts.
+func WithHTTPClient(hc *http.Client) Option {
+	return Option{apply: func(c *client) {
+		// Copy, so TLS config does not modify the given client.
+		cp := *hc
+		c.httpClient = &cp
+	}}
+}
+
+// WithHeaders will send the provided headers with HTTP requests.
+func WithHeaders(headers map[string]string) Option {
+	return Option{otelOpt: otlptracehttp.WithHeaders(headers), apply: func(c *client) { c.headers = headers }}
+}
+
+// WithURLPath sets URL path of the traces endpoint. Defaults to DefaultURLPath.
+func WithURLPath(path string) Option {
+	path = "/" + strings.TrimPrefix(path, "/")
+	return Option{otelOpt: otlptracehttp.WithURLPath(path), apply: func(c *client) { c.urlPath = path }}
+}
+
+// WithJSONEncoding makes exporter send spans in JSON encoding instead of protobuf.
+func WithJSONEncoding() Option {
+	return Option{apply: func(c *client) { c.json = true }}
+}
+
+// client implements otlptrace.Client sending spans over HTTP with custom HTTP client or in JSON encoding, which
+// otlptracehttp client does not support.
+type client struct {
+	endpoint   string
+	scheme     string
+	urlPath    string
+	headers    map[string]string
+	json       bool
+	tlsConfig  *tls.Config
+	httpClient *http.Client
+}
+
+func (c *client) Start(context.Context) error { return nil }
+
+func (c *client) Stop(context.Context) error {
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
+
+func (c *client) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	req := &collectortracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans}
+
+	var (
+		body        []byte
+		err         error
+		contentType = "application/x-protobuf"
+	)
+	if c.json {
+		contentType = "application/json"
+		body, err = marshalJSON(req)
+	} else {
+		body, err = proto.Marshal(req)
+	}
+	if err != nil {
+		return errors.Wrap(err, "marshal export request")
+	}
+
+	u := fmt.Sprintf("%s://%s%s", c.scheme, c.endpoint, c.urlPath)
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "create request")
+	}
+	r.Header.Set("Content-Type", contentType)
+	for k, v := range c.headers {
+		r.Header.Set(k, v)
+	}
+
+	res, err := c.httpClient.Do(r)
+	if err != nil {
+		return errors.Wrapf(err, "post %v", u)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return errors.Errorf("post %v: unexpected status %v: %s", u, res.Status, b)
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	return nil
+}
+
+// idFields are the fields with IDs, which OTLP JSON encoding requires to be hex encoded, instead of base64 used by
+// standard protobuf JSON mapping.
+var idFields = map[string]struct{}{"traceId": {}, "spanId": {}, "parentSpanId": {}}
+
+func marshalJSON(req *collectortracepb.ExportTraceServiceRequest) ([]byte, error) {
+	b, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	if err := hexIDs(v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func hexIDs(v interface{}) error {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, val := range x {
+			if s, ok := val.(string); ok {
+				if _, ok := idFields[k]; ok {
+					id, err := base64.StdEncoding.DecodeString(s)
+					if err != nil {
+						return errors.Wrapf(err, "decode %v", k)
+					}
+					x[k] = hex.EncodeToString(id)
+				}
+				continue
+			}
+			if err := hexIDs(val); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, val := range x {
+			if err := hexIDs(val); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package otlphttp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+type received struct {
+	path, contentType, tenant string
+	body                      []byte
+}
+
+func newServer(t *testing.T, tlsServer bool) (*httptest.Server, chan received) {
+	t.Helper()
+
+	ch := make(chan received, 10)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		testutil.Ok(t, err)
+		ch <- received{path: r.URL.Path, contentType: r.Header.Get("Content-Type"), tenant: r.Header.Get("X-Tenant"), body: b}
+	})
+	if tlsServer {
+		return httptest.NewTLSServer(h), ch
+	}
+	return httptest.NewServer(h), ch
+}
+
+func runApp(t *testing.T, exporter tracing.ExporterBuilder) (traceID string) {
+	t.Helper()
+
+	tr, closeFn, err := tracing.NewTracer(exporter, tracing.WithServiceName("app"))
+	testutil.Ok(t, err)
+	_ = tr.DoInSpan("root", func(ctx context.Context) error {
+		traceID = tracing.GetSpan(ctx).Context().TraceID()
+		return tracing.DoInSpan(ctx, "child", func(context.Context) error { return nil })
+	})
+	testutil.Ok(t, closeFn())
+	return traceID
+}
+
+func TestExporter(t *testing.T) {
+	t.Run("protobuf", func(t *testing.T) {
+		srv, ch := newServer(t, false)
+		defer srv.Close()
+
+		// Zero value option is ignored.
+		runApp(t, Exporter(srv.Listener.Addr().String(), WithInsecure(), WithHeaders(map[string]string{"X-Tenant": "team-a"}), Option{}))
+
+		r := <-ch
+		testutil.Equals(t, DefaultURLPath, r.path)
+		testutil.Equals(t, "application/x-protobuf", r.contentType)
+		testutil.Equals(t, "team-a", r.tenant)
+
+		req := &collectortracepb.ExportTraceServiceRequest{}
+		testutil.Ok(t, proto.Unmarshal(r.body, req))
+		spans := req.GetResourceSpans()[0].GetScopeSpans()[0].GetSpans()
+		testutil.Equals(t, 2, len(spans))
+		testutil.Equals(t, "child", spans[0].GetName())
+		testutil.Equals(t, spans[1].GetSpanId(), spans[0].GetParentSpanId())
+	})
+	t.Run("protobuf with custom HTTP client", func(t *testing.T) {
+		srv, ch := newServer(t, true)
+		defer srv.Close()
+
+		runApp(t, Exporter(srv.Listener.Addr().String(), WithHTTPClient(srv.Client())))
+
+		r := <-ch
+		testutil.Equals(t, DefaultURLPath, r.path)
+		testutil.Equals(t, "application/x-protobuf", r.contentType)
+
+		req := &collectortracepb.ExportTraceServiceRequest{}
+		testutil.Ok(t, proto.Unmarshal(r.body, req))
+		testutil.Equals(t, 2, len(req.GetResourceSpans()[0].GetScopeSpans()[0].GetSpans()))
+	})
+	t.Run("JSON over TLS", func(t *testing.T) {
+		srv, ch := newServer(t, true)
+		defer srv.Close()
+
+		pool := x509.NewCertPool()
+		pool.AddCert(srv.Certificate())
+		traceID := runApp(t, Exporter(
+			srv.Listener.Addr().String(),
+			WithJSONEncoding(),
+			WithURLPath("otlp/v1/traces"),
+			WithTLSClientConfig(&tls.Config{RootCAs: pool}),
+		))
+
+		r := <-ch
+		testutil.Equals(t, "/otlp/v1/traces", r.path)
+		testutil.Equals(t, "application/json", r.contentType)
+
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []struct {
+						TraceID      string `json:"traceId"`
+						SpanID       string `json:"spanId"`
+						ParentSpanID string `json:"parentSpanId"`
+						Name         string `json:"name"`
+						Kind         int    `json:"kind"`
+					} `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		testutil.Ok(t, json.Unmarshal(r.body, &req))
+		spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+		testutil.Equals(t, 2, len(spans))
+		testutil.Equals(t, "child", spans[0].Name)
+		testutil.Equals(t, 1, spans[0].Kind)
+		testutil.Equals(t, traceID, spans[0].TraceID)
+		testutil.Equals(t, spans[1].SpanID, spans[0].ParentSpanID)
+		testutil.Equals(t, 16, len(spans[0].SpanID))
+	})
+	t.Run("error status", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}))
+		defer srv.Close()
+
+		c := &client{endpoint: srv.Listener.Addr().String(), scheme: "http", urlPath: DefaultURLPath, httpClient: srv.Client()}
+		err := c.UploadTraces(context.Background(), nil)
+		testutil.NotOk(t, err)
+		testutil.Equals(t, "post http://"+c.endpoint+"/v1/traces: unexpected status 401 Unauthorized: unauthorized\n", err.Error())
+	})
+}
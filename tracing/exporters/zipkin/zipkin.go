@@ -0,0 +1,45 @@
+package zipkin
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/zipkin"
+)
+
+// Option represents Zipkin exporter option.
+type Option struct {
+	zipkinOpt zipkin.Option
+}
+
+// Exporter sets the Zipkin v2 JSON exporter builder for spans that can be used in tracing.WithExporter().
+// URL is the Zipkin collector URL, e.g. http://localhost:9411/api/v2/spans.
+//
+// Span kinds are mapped to Zipkin kinds, events to annotations, status to "otel.status_code" and "error" tags and
+// resource "service.name" to the local endpoint service name. Other attributes are sent as tags.
+func Exporter(url string, opts ...Option) tracing.ExporterBuilder {
+	zopts := make([]zipkin.Option, 0, len(opts))
+	for _, o := range opts {
+		zopts = append(zopts, o.zipkinOpt)
+	}
+
+	return func() (tracing.Exporter, error) {
+		e, err := zipkin.New(url, zopts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "Zipkin exporter creation")
+		}
+		return e, nil
+	}
+}
+
+// WithHTTPClient sets the http client to be used to make request to the collector endpoint.
+func WithHTTPClient(client *http.Client) Option {
+	return Option{zipkinOpt: zipkin.WithClient(client)}
+}
+
+// WithLogger sets the logger for the exporter debug messages. By default, nothing is logged.
+func WithLogger(logger *log.Logger) Option {
+	return Option{zipkinOpt: zipkin.WithLogger(logger)}
+}
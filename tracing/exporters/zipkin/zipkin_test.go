@@ -0,0 +1,92 @@
+package zipkin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+	"github.com/pkg/errors"
+)
+
+// span is the subset of Zipkin v2 span JSON model.
+type span struct {
+	TraceID       string `json:"traceId"`
+	ID            string `json:"id"`
+	ParentID      string `json:"parentId"`
+	Name          string `json:"name"`
+	Kind          string `json:"kind"`
+	LocalEndpoint struct {
+		ServiceName string `json:"serviceName"`
+	} `json:"localEndpoint"`
+	Annotations []struct {
+		Value string `json:"value"`
+	} `json:"annotations"`
+	Tags map[string]string `json:"tags"`
+}
+
+func TestExporter(t *testing.T) {
+	ch := make(chan []span, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Equals(t, "/api/v2/spans", r.URL.Path)
+		testutil.Equals(t, "application/json", r.Header.Get("Content-Type"))
+
+		var spans []span
+		testutil.Ok(t, json.NewDecoder(r.Body).Decode(&spans))
+		ch <- spans
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	tr, closeFn, err := tracing.NewTracer(
+		Exporter(srv.URL+"/api/v2/spans", WithHTTPClient(srv.Client())),
+		tracing.WithServiceName("app"),
+	)
+	testutil.Ok(t, err)
+
+	var traceID string
+	_ = tr.DoInSpan("root", func(ctx context.Context) error {
+		traceID = tracing.GetSpan(ctx).Context().TraceID()
+		_ = tracing.DoInSpan(ctx, "child", func(ctx context.Context) error {
+			tracing.GetSpan(ctx).AddEvent("cache miss", "key", "a")
+			tracing.GetSpan(ctx).SetAttributes("user", "bwplotka")
+			return errors.New("not found")
+		}, tracing.WithStartSpanKind(tracing.SpanKindClient))
+		return nil
+	}, tracing.WithTracerStartSpanKind(tracing.SpanKindServer))
+	testutil.Ok(t, closeFn())
+
+	var spans []span
+	for len(spans) < 2 {
+		spans = append(spans, <-ch...)
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Name > spans[j].Name })
+	root, child := spans[0], spans[1]
+
+	testutil.Equals(t, "root", root.Name)
+	testutil.Equals(t, traceID, root.TraceID)
+	testutil.Equals(t, "", root.ParentID)
+	testutil.Equals(t, "SERVER", root.Kind)
+	testutil.Equals(t, "app", root.LocalEndpoint.ServiceName)
+	testutil.Equals(t, "Ok", root.Tags["otel.status_code"])
+
+	testutil.Equals(t, "child", child.Name)
+	testutil.Equals(t, traceID, child.TraceID)
+	testutil.Equals(t, root.ID, child.ParentID)
+	testutil.Equals(t, "CLIENT", child.Kind)
+	testutil.Equals(t, "app", child.LocalEndpoint.ServiceName)
+	testutil.Equals(t, "Error", child.Tags["otel.status_code"])
+	testutil.Equals(t, "not found", child.Tags["error"])
+	testutil.Equals(t, "bwplotka", child.Tags["user"])
+	testutil.Equals(t, 1, len(child.Annotations))
+	testutil.Equals(t, `cache miss: {"key":"a"}`, child.Annotations[0].Value)
+}
+
+func TestExporter_InvalidURL(t *testing.T) {
+	_, err := Exporter("localhost:9411")()
+	testutil.NotOk(t, err)
+}
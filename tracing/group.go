@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Go runs `f` function in a new goroutine inside child span of the span in the context (see DoInSpan).
+// Error returned by `f` is recorded on the span only. Use Group if you want to wait for the goroutines and check errors.
+func Go(ctx context.Context, spanName string, f func(ctx context.Context) error, opts ...StartSpanOption) {
+	go func() { _ = DoInSpan(ctx, spanName, f, opts...) }()
+}
+
+// Group is a collection of goroutines working on subtasks of the same task, similar to
+// golang.org/x/sync/errgroup.Group, where each goroutine runs inside its own child span.
+// The first goroutine returning error cancels the group context; running siblings get "cancelled" event
+// with the cause on their spans.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	span   Span
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	err     error
+	started int
+	failed  int
+}
+
+// NewGroup returns new Group and derived context, which is cancelled when any goroutine returns error or when Wait
+// returns, whichever occurs first. Group goroutine spans are children of the span from the given context.
+func NewGroup(ctx context.Context) (*Group, context.Context) {
+	gctx, cancel := context.WithCancelCause(ctx)
+	return &Group{ctx: gctx, cancel: cancel, span: GetSpan(ctx)}, gctx
+}
+
+// Go runs `f` function in a new goroutine inside child span.
+func (g *Group) Go(spanName string, f func(ctx context.Context) error, opts ...StartSpanOption) {
+	g.mu.Lock()
+	g.started++
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		err := DoInSpan(g.ctx, spanName, func(ctx context.Context) error {
+			span := GetSpan(ctx)
+			recorded := make(chan struct{})
+			stop := context.AfterFunc(ctx, func() {
+				span.AddEvent("cancelled", "cause", context.Cause(ctx).Error())
+				close(recorded)
+			})
+			defer func() {
+				if !stop() {
+					// Cancellation happened, make sure event is recorded before span ends.
+					<-recorded
+				}
+			}()
+			return f(ctx)
+		}, opts...)
+		if err == nil {
+			return
+		}
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.failed++
+		if g.err == nil {
+			g.err = err
+			g.cancel(errors.Wrapf(err, "group goroutine %q failed", spanName))
+		}
+	}()
+}
+
+// Wait blocks until all goroutines from Go method calls have returned, then returns the first non-nil error (if any).
+// Number of started and failed goroutines is recorded as attributes of the group parent span.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(context.Canceled)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.span.SetAttributes("group.goroutines", g.started, "group.failed", g.failed)
+	return g.err
+}
@@ -0,0 +1,51 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestGroup(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t)
+
+	ctx, root := tr.StartSpan("root")
+	g, gctx := tracing.NewGroup(ctx)
+	g.Go("ok", func(context.Context) error { return nil })
+	g.Go("waiting", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	g.Go("failing", func(context.Context) error { return errors.New("fail") })
+	testutil.NotOk(t, g.Wait())
+	testutil.NotOk(t, gctx.Err())
+	root.End(nil)
+
+	tracing.Go(ctx, "go", func(context.Context) error { return nil })
+	// Span is ended after the function returns, so wait until it's exported.
+	for deadline := time.Now().Add(10 * time.Second); len(rec.Spans().ByName("go")) == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for go span")
+		}
+	}
+
+	byName := map[string]tracingtest.Span{}
+	for _, s := range rec.Spans() {
+		byName[s.Name] = s
+	}
+	testutil.Equals(t, 5, len(byName))
+	for _, name := range []string{"ok", "waiting", "failing", "go"} {
+		testutil.Equals(t, root.Context().SpanID(), byName[name].ParentSpanID)
+	}
+	testutil.Equals(t, "error: fail", byName["failing"].Status)
+	testutil.Equals(t, 1, len(byName["waiting"].Events))
+	testutil.Equals(t, "cancelled", byName["waiting"].Events[0].Name)
+	testutil.Equals(t, `group goroutine "failing" failed: fail`, byName["waiting"].Events[0].Attributes["cause"])
+
+	testutil.Equals(t, map[string]string{"group.goroutines": "3", "group.failed": "1"}, byName["root"].Attributes)
+}
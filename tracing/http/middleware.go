@@ -2,8 +2,10 @@ package tracinghttp
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"unicode/utf8"
 
 	"github.com/bwplotka/tracing-go/tracing"
 	"github.com/felixge/httpsnoop"
@@ -18,19 +20,81 @@ import (
 // Here we want to just focus on tracing.
 type Middleware struct {
 	tracer *tracing.Tracer
+	opts   middlewareOptions
 }
 
-func NewMiddleware(tracer *tracing.Tracer) *Middleware {
-	return &Middleware{tracer: tracer}
+// MiddlewareOption sets the value of an option for a Middleware.
+type MiddlewareOption func(*middlewareOptions)
+
+// maxDebugIDLength is the maximum length of the debug header value.
+const maxDebugIDLength = 64
+
+type middlewareOptions struct {
+	debugHeader   string
+	debugAllowFn  func(*http.Request) bool
+	recoverPanics bool
 }
 
-func (m *Middleware) WrapHandler(name string, next http.Handler) http.HandlerFunc {
-	propagator := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+// WithDebugHeader enables forced sampling of the whole trace for requests with non-empty given header
+// (e.g. "X-Tracing-Debug" or "jaeger-debug-id"), regardless of the sampler configured in the Tracer.
+// The header value (truncated to at most 64 bytes) is set as tracing.DebugAttributeKey attribute on all spans of such request.
+// Use Tripperware WithDebugHeaderPropagation to propagate debug mode to the downstream services.
+// Optional allowFn decides which requests (clients) are allowed to trigger debug mode. If nil, all requests are allowed.
+func WithDebugHeader(header string, allowFn func(r *http.Request) bool) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.debugHeader = header
+		o.debugAllowFn = allowFn
+	}
+}
+
+// WithPanicRecovery makes middleware recover panics from the handler. Panic is recorded on the span (see
+// tracing.RecordPanic) and turned into 500 response with the trace ID in the body, unless handler already started
+// writing the response. Without this option, panic is recorded on the span and re-panicked.
+// http.ErrAbortHandler is always re-panicked without recording it as an exception.
+func WithPanicRecovery() MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.recoverPanics = true
+	}
+}
+
+func NewMiddleware(tracer *tracing.Tracer, opts ...MiddlewareOption) *Middleware {
+	m := &Middleware{tracer: tracer}
+	for _, opt := range opts {
+		opt(&m.opts)
+	}
+	return m
+}
+
+func (m *Middleware) debugID(r *http.Request) (string, bool) {
+	if m.opts.debugHeader == "" {
+		return "", false
+	}
+	id := r.Header.Get(m.opts.debugHeader)
+	if id == "" {
+		return "", false
+	}
+	if m.opts.debugAllowFn != nil && !m.opts.debugAllowFn(r) {
+		return "", false
+	}
+	if len(id) > maxDebugIDLength {
+		// Header is set by the client, so don't let it put arbitrary large values into all spans.
+		// Cut on the rune boundary, so attribute stays valid UTF-8.
+		n := maxDebugIDLength
+		for n > 0 && !utf8.RuneStart(id[n]) {
+			n--
+		}
+		id = id[:n]
+	}
+	return id, true
+}
 
+func (m *Middleware) WrapHandler(name string, next http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx, span := m.tracer.StartSpan(name, tracing.WithTracerStartSpanContext(
-			propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))),
-		)
+		pctx := m.tracer.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		if id, ok := m.debugID(r); ok {
+			pctx = tracing.ContextWithDebug(pctx, id)
+		}
+		ctx, span := m.tracer.StartSpan(name, tracing.WithTracerStartSpanContext(pctx))
 		span.SetAttributes(attrToKv(semconv.NetAttributesFromHTTPRequest("tcp", r)...))
 		span.SetAttributes(attrToKv(semconv.EndUserAttributesFromHTTPRequest(r)...))
 		span.SetAttributes(attrToKv(semconv.HTTPServerAttributesFromHTTPRequest(name, "", r)...))
@@ -67,7 +131,7 @@ func (m *Middleware) WrapHandler(name string, next http.Handler) http.HandlerFun
 		})
 
 		// Perform handler.
-		next.ServeHTTP(w, r.WithContext(ctx))
+		panicErr := m.serve(next, w, rww, r.WithContext(ctx), span)
 
 		var postServeAttrs []interface{}
 
@@ -91,6 +155,10 @@ func (m *Middleware) WrapHandler(name string, next http.Handler) http.HandlerFun
 		}
 		span.SetAttributes(postServeAttrs...)
 
+		if panicErr != nil {
+			span.End(panicErr)
+			return
+		}
 		if rww.statusCode == http.StatusOK {
 			span.End(nil)
 			return
@@ -99,6 +167,36 @@ func (m *Middleware) WrapHandler(name string, next http.Handler) http.HandlerFun
 	}
 }
 
+// serve runs handler. If handler panics and panic recovery is enabled, it returns error representing the panic.
+// 500 response is written only if handler did not write the response yet (rww tracks that).
+func (m *Middleware) serve(next http.Handler, w http.ResponseWriter, rww *respWriterWrapper, r *http.Request, span tracing.Span) (panicErr error) {
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+		if p == http.ErrAbortHandler {
+			// Handler was aborted on purpose, it's not an exception. Let net/http handle it.
+			span.End(http.ErrAbortHandler)
+			panic(p)
+		}
+		panicErr = tracing.RecordPanic(span, p)
+		if !m.opts.recoverPanics {
+			span.End(panicErr)
+			panic(p)
+		}
+		if rww.wroteHeader {
+			// Response is already (partially) written, nothing more can be done.
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "%s\ntrace ID: %s\n", http.StatusText(http.StatusInternalServerError), span.Context().TraceID())
+	}()
+	next.ServeHTTP(w, r)
+	return nil
+}
+
 func attrToKv(kvs ...attribute.KeyValue) []interface{} {
 	if len(kvs) == 0 {
 		return nil
@@ -0,0 +1,172 @@
+package tracinghttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestMiddleware_DebugHeader(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t, tracing.WithSampler(tracing.TraceIDRatioBasedSampler(0)))
+
+	m := NewMiddleware(tr, WithDebugHeader("X-Tracing-Debug", func(r *http.Request) bool {
+		return r.Header.Get("X-Allowed") != ""
+	}))
+	h := m.WrapHandler("handler", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = tracing.DoInSpan(r.Context(), "child", func(context.Context) error { return nil })
+	}))
+
+	for _, hdr := range []map[string]string{
+		{},
+		{"X-Tracing-Debug": "true"},
+		{"X-Tracing-Debug": "abc", "X-Allowed": "yes"},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		for k, v := range hdr {
+			r.Header.Set(k, v)
+		}
+		h(httptest.NewRecorder(), r)
+	}
+	spans := rec.Spans()
+	testutil.Equals(t, 2, len(spans))
+	for _, s := range spans {
+		testutil.Equals(t, "abc", s.Attributes[tracing.DebugAttributeKey])
+	}
+	testutil.Equals(t, spans[1].SpanID, spans[0].ParentSpanID)
+}
+
+func TestMiddleware_DebugHeaderPropagation(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t, tracing.WithSampler(tracing.TraceIDRatioBasedSampler(0)))
+	m := NewMiddleware(tr, WithDebugHeader("X-Tracing-Debug", nil))
+
+	var downstreamDebugHeader string
+	downstream := httptest.NewServer(m.WrapHandler("downstream", http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		downstreamDebugHeader = r.Header.Get("X-Tracing-Debug")
+	})))
+	defer downstream.Close()
+
+	c := &http.Client{Transport: NewTripperware(WithDebugHeaderPropagation("X-Tracing-Debug")).WrapRoundTipper("client", http.DefaultTransport)}
+	h := m.WrapHandler("upstream", http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		req, err := http.NewRequestWithContext(r.Context(), "GET", downstream.URL, nil)
+		testutil.Ok(t, err)
+		res, err := c.Do(req)
+		testutil.Ok(t, err)
+		_ = res.Body.Close()
+	}))
+
+	// Too long debug ID is truncated on the rune boundary.
+	longID := "a" + strings.Repeat("é", 50)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Tracing-Debug", longID)
+	h(httptest.NewRecorder(), r)
+
+	testutil.Equals(t, longID[:63], downstreamDebugHeader)
+	spans := rec.Spans()
+	testutil.Equals(t, 3, len(spans))
+	for _, s := range spans {
+		testutil.Equals(t, longID[:63], s.Attributes[tracing.DebugAttributeKey], s.Name)
+	}
+}
+
+func TestMiddleware_Propagators(t *testing.T) {
+	for _, tcase := range []struct {
+		name           string
+		client, server []tracing.Propagator
+	}{
+		{name: "default"},
+		{name: "b3 single", client: []tracing.Propagator{tracing.B3SingleHeaderPropagator()}, server: []tracing.Propagator{tracing.JaegerPropagator(), tracing.B3Propagator()}},
+		{name: "b3 multi", client: []tracing.Propagator{tracing.B3Propagator()}, server: []tracing.Propagator{tracing.TraceContextPropagator(), tracing.B3Propagator()}},
+		{name: "jaeger", client: []tracing.Propagator{tracing.JaegerPropagator()}, server: []tracing.Propagator{tracing.B3Propagator(), tracing.JaegerPropagator()}},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			serverTr, serverRec := tracingtest.NewTracer(t, tracing.WithPropagators(tcase.server...))
+			srv := httptest.NewServer(NewMiddleware(serverTr).WrapHandler("server", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})))
+			defer srv.Close()
+
+			clientTr, clientRec := tracingtest.NewTracer(t, tracing.WithPropagators(tcase.client...))
+			c := &http.Client{Transport: NewTripperware().WrapRoundTipper("client", http.DefaultTransport)}
+			testutil.Ok(t, clientTr.DoInSpan("root", func(ctx context.Context) error {
+				r, err := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+				testutil.Ok(t, err)
+				res, err := c.Do(r)
+				testutil.Ok(t, err)
+				return res.Body.Close()
+			}))
+
+			clientSpans := clientRec.Spans()
+			serverSpans := serverRec.Spans()
+			testutil.Equals(t, 2, len(clientSpans))
+			testutil.Equals(t, 1, len(serverSpans))
+			testutil.Equals(t, "client", clientSpans[0].Name)
+			testutil.Equals(t, clientSpans[0].TraceID, serverSpans[0].TraceID)
+			testutil.Equals(t, clientSpans[0].SpanID, serverSpans[0].ParentSpanID)
+			testutil.Assert(t, serverSpans[0].ParentRemote)
+		})
+	}
+}
+
+func TestMiddleware_PanicRecovery(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t)
+
+	h := NewMiddleware(tr, WithPanicRecovery()).WrapHandler("handler", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest("GET", "/", nil))
+
+	spans := rec.Spans()
+	testutil.Equals(t, 1, len(spans))
+	testutil.Equals(t, http.StatusInternalServerError, w.Code)
+	testutil.Equals(t, "Internal Server Error\ntrace ID: "+spans[0].TraceID+"\n", w.Body.String())
+	testutil.Equals(t, "error: panic: boom", spans[0].Status)
+	testutil.Equals(t, "exception", spans[0].Events[0].Name)
+
+	// Response already written by handler is not overwritten.
+	tr, rec = tracingtest.NewTracer(t)
+	h = NewMiddleware(tr, WithPanicRecovery()).WrapHandler("handler", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("partial"))
+		panic("boom")
+	}))
+	w = httptest.NewRecorder()
+	h(w, httptest.NewRequest("GET", "/", nil))
+
+	spans = rec.Spans()
+	testutil.Equals(t, 1, len(spans))
+	testutil.Equals(t, http.StatusAccepted, w.Code)
+	testutil.Equals(t, "partial", w.Body.String())
+	testutil.Equals(t, "error: panic: boom", spans[0].Status)
+
+	// Without recovery, panic is recorded and propagated.
+	tr, rec = tracingtest.NewTracer(t)
+	h = NewMiddleware(tr).WrapHandler("handler", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+	func() {
+		defer func() { testutil.Equals(t, "boom", recover()) }()
+		h(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	spans = rec.Spans()
+	testutil.Equals(t, 1, len(spans))
+	testutil.Equals(t, "exception", spans[0].Events[0].Name)
+
+	// http.ErrAbortHandler is propagated without recording exception, even with recovery.
+	tr, rec = tracingtest.NewTracer(t)
+	h = NewMiddleware(tr, WithPanicRecovery()).WrapHandler("handler", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	func() {
+		defer func() { testutil.Equals(t, http.ErrAbortHandler, recover()) }()
+		h(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	spans = rec.Spans()
+	testutil.Equals(t, 1, len(spans))
+	testutil.Equals(t, 0, len(spans[0].Events))
+	testutil.Equals(t, "error: "+http.ErrAbortHandler.Error(), spans[0].Status)
+}
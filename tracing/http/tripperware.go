@@ -14,10 +14,31 @@ import (
 // The "go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp" module instruments many too things.
 // Here we want to just focus on tracing.
 type Tripperware struct {
+	opts tripperwareOptions
 }
 
-func NewTripperware() *Tripperware {
-	return &Tripperware{}
+// TripperwareOption sets the value of an option for a Tripperware.
+type TripperwareOption func(*tripperwareOptions)
+
+type tripperwareOptions struct {
+	debugHeader string
+}
+
+// WithDebugHeaderPropagation makes Tripperware set the given header (e.g. "X-Tracing-Debug") to the debug ID for
+// requests done in the debug mode (see tracing.ContextWithDebug and Middleware WithDebugHeader), so the downstream
+// services sample their part of the trace too.
+func WithDebugHeaderPropagation(header string) TripperwareOption {
+	return func(o *tripperwareOptions) {
+		o.debugHeader = header
+	}
+}
+
+func NewTripperware(opts ...TripperwareOption) *Tripperware {
+	t := &Tripperware{}
+	for _, opt := range opts {
+		opt(&t.opts)
+	}
+	return t
 }
 
 type rtFunc func(*http.Request) (*http.Response, error)
@@ -26,16 +47,17 @@ func (rt rtFunc) RoundTrip(r *http.Request) (*http.Response, error) {
 	return rt(r)
 }
 
-func (Tripperware) WrapRoundTipper(name string, next http.RoundTripper) http.RoundTripper {
-	propagator := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
-
+func (t Tripperware) WrapRoundTipper(name string, next http.RoundTripper) http.RoundTripper {
 	return rtFunc(func(r *http.Request) (*http.Response, error) {
 		ctx, span := tracing.StartSpan(r.Context(), name)
 		span.SetAttributes(attrToKv(semconv.NetAttributesFromHTTPRequest("tcp", r)...))
 		span.SetAttributes(attrToKv(semconv.EndUserAttributesFromHTTPRequest(r)...))
 		span.SetAttributes(attrToKv(semconv.HTTPServerAttributesFromHTTPRequest(name, "", r)...))
 
-		propagator.Inject(ctx, propagation.HeaderCarrier(r.Header))
+		tracing.Inject(ctx, propagation.HeaderCarrier(r.Header))
+		if id, ok := tracing.DebugIDFromContext(ctx); ok && t.opts.debugHeader != "" {
+			r.Header.Set(t.opts.debugHeader, id)
+		}
 
 		// Perform round trip.
 		res, err := next.RoundTrip(r.WithContext(ctx))
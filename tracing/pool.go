@@ -0,0 +1,124 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrWorkerPoolClosed is returned when job is submitted to the closed WorkerPool.
+var ErrWorkerPoolClosed = errors.New("worker pool closed")
+
+// WorkerPool is a bounded pool of workers processing jobs from the queue. Each job is done inside span started when
+// the worker picks the job up, from the span context of the submitter. The span has "queue.wait" (time job spent in
+// the queue) and "queue.depth" (number of jobs in the queue when job was submitted) attributes.
+type WorkerPool struct {
+	jobs chan queuedJob
+	opts workerPoolOptions
+
+	// done is closed on Close, so blocked Submit calls return without holding the lock.
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type queuedJob struct {
+	ctx      context.Context
+	spanName string
+	f        func(context.Context) error
+	enqueued time.Time
+	depth    int
+}
+
+// WorkerPoolOption sets the value of an option for a WorkerPool.
+type WorkerPoolOption func(*workerPoolOptions)
+
+type workerPoolOptions struct {
+	linked bool
+}
+
+// WithLinkedJobSpans makes job spans new roots linked to the submitter span, instead of submitter span children.
+// Useful when jobs are processed long after submitter finished its work.
+func WithLinkedJobSpans() WorkerPoolOption {
+	return func(o *workerPoolOptions) {
+		o.linked = true
+	}
+}
+
+// NewWorkerPool starts WorkerPool with given number of workers and queue of given size.
+func NewWorkerPool(workers, queueSize int, opts ...WorkerPoolOption) *WorkerPool {
+	p := &WorkerPool{jobs: make(chan queuedJob, queueSize), done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(&p.opts)
+	}
+
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer p.wg.Done()
+			for j := range p.jobs {
+				p.do(j)
+			}
+		}()
+	}
+	return p
+}
+
+// Submit enqueues `f` function to be done by the worker inside span. It blocks until job is enqueued, the context
+// is done or the pool is closed. Error returned by `f` is recorded on the span only.
+// Job is done with the context values of the given context, but without its cancellation, so jobs submitted e.g.
+// from HTTP handlers are not cancelled when the request finishes.
+func (p *WorkerPool) Submit(ctx context.Context, spanName string, f func(context.Context) error) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrWorkerPoolClosed
+	}
+
+	select {
+	case p.jobs <- queuedJob{ctx: context.WithoutCancel(ctx), spanName: spanName, f: f, enqueued: time.Now(), depth: len(p.jobs)}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.done:
+		return ErrWorkerPoolClosed
+	}
+}
+
+func (p *WorkerPool) do(j queuedJob) {
+	opts := []StartSpanOption{WithStartSpanAttributes(
+		"queue.wait", time.Since(j.enqueued),
+		"queue.depth", j.depth,
+	)}
+	ctx := j.ctx
+	if p.opts.linked {
+		parent := GetSpan(ctx).Context()
+		ctx = trace.ContextWithSpanContext(ctx, trace.SpanContext{})
+		opts = append(opts, WithStartSpanLinks(parent))
+	}
+	_ = DoInSpan(ctx, j.spanName, j.f, opts...)
+}
+
+// Close stops accepting new jobs and waits until all queued jobs are done. Submit calls blocked on the full queue
+// return ErrWorkerPoolClosed.
+func (p *WorkerPool) Close() {
+	// Unblock submitters first, so the lock can be taken.
+	p.closeOnce.Do(func() { close(p.done) })
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.jobs)
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
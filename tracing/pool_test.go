@@ -0,0 +1,108 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestWorkerPool(t *testing.T) {
+	for _, linked := range []bool{false, true} {
+		var opts []tracing.WorkerPoolOption
+		if linked {
+			opts = append(opts, tracing.WithLinkedJobSpans())
+		}
+
+		tr, rec := tracingtest.NewTracer(t)
+		p := tracing.NewWorkerPool(1, 2, opts...)
+
+		ctx, root := tr.StartSpan("submitter")
+		block := make(chan struct{})
+		testutil.Ok(t, p.Submit(ctx, "blocking job", func(context.Context) error {
+			<-block
+			return nil
+		}))
+		testutil.Ok(t, p.Submit(ctx, "job", func(context.Context) error { return nil }))
+		time.Sleep(10 * time.Millisecond)
+		close(block)
+		root.End(nil)
+
+		p.Close()
+		testutil.Equals(t, tracing.ErrWorkerPoolClosed, p.Submit(ctx, "job", func(context.Context) error { return nil }))
+
+		byName := map[string]tracingtest.Span{}
+		for _, s := range rec.Spans() {
+			byName[s.Name] = s
+		}
+		testutil.Equals(t, 3, len(byName))
+		job, submitter := byName["job"], byName["submitter"]
+		if linked {
+			testutil.Equals(t, "", job.ParentSpanID)
+			testutil.Assert(t, job.TraceID != submitter.TraceID)
+			testutil.Equals(t, []string{submitter.SpanID}, job.LinkedSpanIDs)
+		} else {
+			testutil.Equals(t, submitter.SpanID, job.ParentSpanID)
+		}
+
+		wait, err := time.ParseDuration(job.Attributes["queue.wait"])
+		testutil.Ok(t, err)
+		testutil.Assert(t, wait >= 10*time.Millisecond, "expected queue wait at least 10ms, got %v", wait)
+		testutil.Equals(t, "1", job.Attributes["queue.depth"])
+	}
+}
+
+func TestWorkerPool_SubmitterContextCancelled(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t)
+	p := tracing.NewWorkerPool(1, 1)
+
+	ctx, root := tr.StartSpan("request")
+	ctx, cancel := context.WithCancel(ctx)
+	jobErr := make(chan error, 1)
+	testutil.Ok(t, p.Submit(ctx, "background job", func(ctx context.Context) error {
+		<-time.After(10 * time.Millisecond)
+		jobErr <- ctx.Err()
+		return nil
+	}))
+	// Request finishes before the job is done.
+	cancel()
+	root.End(nil)
+
+	testutil.Ok(t, <-jobErr)
+	p.Close()
+
+	spans := rec.Spans()
+	testutil.Equals(t, 2, len(spans))
+	testutil.Equals(t, "background job", spans[1].Name)
+	testutil.Equals(t, spans[0].SpanID, spans[1].ParentSpanID)
+}
+
+func TestWorkerPool_CloseUnblocksSubmit(t *testing.T) {
+	p := tracing.NewWorkerPool(1, 1)
+
+	block := make(chan struct{})
+	testutil.Ok(t, p.Submit(context.Background(), "blocking job", func(context.Context) error {
+		<-block
+		return nil
+	}))
+	// Fill the queue.
+	testutil.Ok(t, p.Submit(context.Background(), "job", func(context.Context) error { return nil }))
+
+	submitErr := make(chan error, 1)
+	go func() {
+		submitErr <- p.Submit(context.Background(), "job", func(context.Context) error { return nil })
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+	testutil.Equals(t, tracing.ErrWorkerPoolClosed, <-submitErr)
+	close(block)
+	<-closed
+}
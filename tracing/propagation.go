@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// TraceContextPropagator returns propagator of W3C Trace Context format (traceparent and tracestate headers).
+func TraceContextPropagator() Propagator { return propagation.TraceContext{} }
+
+// BaggagePropagator returns propagator of W3C Baggage format (baggage header).
+func BaggagePropagator() Propagator { return propagation.Baggage{} }
+
+// B3Propagator returns propagator of Zipkin B3 format. It extracts both single (b3) and multi (X-B3-*) header
+// encodings, but injects only multi header one.
+func B3Propagator() Propagator { return b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)) }
+
+// B3SingleHeaderPropagator returns propagator of Zipkin B3 format. It extracts both single (b3) and multi (X-B3-*) header
+// encodings, but injects only single header one.
+func B3SingleHeaderPropagator() Propagator { return b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)) }
+
+// JaegerPropagator returns propagator of legacy Jaeger format (uber-trace-id header).
+func JaegerPropagator() Propagator { return jaeger.Jaeger{} }
+
+// propagators is a composite propagator. On injection, all propagators are used. On extraction, all propagators are tried
+// and the first one (in configured order) that finds trace context wins.
+type propagators []Propagator
+
+func (p propagators) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	for _, pr := range p {
+		pr.Inject(ctx, carrier)
+	}
+}
+
+func (p propagators) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	// Extract in reverse order, so context extracted by the earlier propagator overrides the later one.
+	for i := len(p) - 1; i >= 0; i-- {
+		ctx = p[i].Extract(ctx, carrier)
+	}
+	return ctx
+}
+
+func (p propagators) Fields() []string {
+	unique := map[string]struct{}{}
+	var fields []string
+	for _, pr := range p {
+		for _, f := range pr.Fields() {
+			if _, ok := unique[f]; ok {
+				continue
+			}
+			unique[f] = struct{}{}
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+var defaultPropagators = propagators{TraceContextPropagator(), BaggagePropagator()}
+
+type tracerKey struct{}
+
+// ContextWithTracer returns context with the given tracer, so StartSpan and DoInSpan can create spans from it
+// (or from any context derived from it). If the context does not have any span (including remote one e.g. from Extract),
+// created span is a root span.
+func ContextWithTracer(ctx context.Context, tr *Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, tr)
+}
+
+func tracerFromContext(ctx context.Context) *Tracer {
+	tr, _ := ctx.Value(tracerKey{}).(*Tracer)
+	return tr
+}
+
+// PropagatorFromContext returns propagator configured in the Tracer that created spans in the given context.
+// If context is not chained to any Tracer, W3C Trace Context and Baggage propagator is returned.
+func PropagatorFromContext(ctx context.Context) Propagator {
+	if tr := tracerFromContext(ctx); tr != nil {
+		return tr.Propagator()
+	}
+	return defaultPropagators
+}
+
+// Inject injects trace context (and baggage) from the given context into carrier (e.g. MapCarrier for message
+// headers), using propagator of the Tracer that created spans in the given context (see PropagatorFromContext).
+func Inject(ctx context.Context, carrier Carrier) {
+	PropagatorFromContext(ctx).Inject(ctx, carrier)
+}
+
+// Extract extracts remote trace context (and baggage) from carrier into returned context,
+// using propagator of the Tracer chained to the given context (see PropagatorFromContext).
+// Use Tracer.Extract if context is not chained to any Tracer.
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	return PropagatorFromContext(ctx).Extract(ctx, carrier)
+}
+
+// Extract extracts remote trace context (and baggage) from carrier into returned context using Tracer propagator.
+// Returned context also carries this Tracer (see ContextWithTracer), so StartSpan can start span that is a child of
+// the remote span. Alternatively, use it with WithTracerStartSpanContext, e.g.
+//
+//	ctx, span := tr.StartSpan("consume", tracing.WithTracerStartSpanContext(tr.Extract(ctx, tracing.MapCarrier(msg.Headers))))
+func (tr *Tracer) Extract(ctx context.Context, carrier Carrier) context.Context {
+	return ContextWithTracer(tr.propagator.Extract(ctx, carrier), tr)
+}
@@ -0,0 +1,62 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestInjectExtract(t *testing.T) {
+	producerTr, producerRec := tracingtest.NewTracer(t, tracing.WithPropagators(tracing.B3SingleHeaderPropagator()))
+	consumerTr, consumerRec := tracingtest.NewTracer(t, tracing.WithPropagators(tracing.TraceContextPropagator(), tracing.B3Propagator()))
+
+	headers := map[string]string{}
+	testutil.Ok(t, producerTr.DoInSpan("produce", func(ctx context.Context) error {
+		tracing.Inject(ctx, tracing.MapCarrier(headers))
+		return nil
+	}))
+	testutil.Equals(t, 1, len(headers))
+	testutil.Assert(t, headers["b3"] != "")
+
+	_, span := consumerTr.StartSpan("consume", tracing.WithTracerStartSpanContext(consumerTr.Extract(context.Background(), tracing.MapCarrier(headers))))
+	span.End(nil)
+
+	producerSpans := producerRec.Spans()
+	consumerSpans := consumerRec.Spans()
+	testutil.Equals(t, 1, len(producerSpans))
+	testutil.Equals(t, 1, len(consumerSpans))
+	testutil.Equals(t, producerSpans[0].TraceID, consumerSpans[0].TraceID)
+	testutil.Equals(t, producerSpans[0].SpanID, consumerSpans[0].ParentSpanID)
+	testutil.Assert(t, consumerSpans[0].ParentRemote)
+}
+
+func TestContextWithTracer(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t)
+
+	// Without tracer, spans are not recorded.
+	_, span := tracing.StartSpan(context.Background(), "orphan")
+	span.End(nil)
+	testutil.Assert(t, !span.Context().IsSampled())
+
+	ctx, root := tracing.StartSpan(tracing.ContextWithTracer(context.Background(), tr), "root")
+	testutil.Ok(t, tracing.DoInSpan(ctx, "child", func(context.Context) error { return nil }))
+	root.End(nil)
+
+	carrier := tracing.MapCarrier{}
+	tracing.Inject(ctx, carrier)
+	_, remote := tracing.StartSpan(tr.Extract(context.Background(), carrier), "remote child")
+	remote.End(nil)
+
+	spans := rec.Spans()
+	testutil.Equals(t, 3, len(spans))
+	testutil.Equals(t, "child", spans[0].Name)
+	testutil.Equals(t, "root", spans[1].Name)
+	testutil.Equals(t, "", spans[1].ParentSpanID)
+	testutil.Equals(t, spans[1].SpanID, spans[0].ParentSpanID)
+	testutil.Equals(t, "remote child", spans[2].Name)
+	testutil.Equals(t, spans[0].ParentSpanID, spans[2].ParentSpanID)
+	testutil.Assert(t, spans[2].ParentRemote)
+}
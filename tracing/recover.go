@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/pkg/errors"
+)
+
+// Recover recovers panic (if any), records it on the span using RecordPanic, ends the span with error and re-panics.
+// It has to be deferred directly and after (so it runs before) deferred End, e.g.
+//
+//	ctx, span := tracing.StartSpan(ctx, "operation")
+//	defer span.End(nil)
+//	defer tracing.Recover(span)
+//
+// DoInSpan (and its variants) do it automatically.
+func Recover(s Span) {
+	if r := recover(); r != nil {
+		endWithPanic(s, r)
+		panic(r)
+	}
+}
+
+// RecoverToError is like Recover, but instead of re-panicking, it sets the error that the panic was recorded as
+// to the given err pointer, e.g. named error result of the function.
+func RecoverToError(s Span, err *error) {
+	if r := recover(); r != nil {
+		*err = endWithPanic(s, r)
+	}
+}
+
+// RecordPanic records panic value as an "exception" event with the current stack trace on the span, following
+// OpenTelemetry semantic conventions. It returns error representing panic, that can be used to end the span.
+// It's useful for custom recovery logic, otherwise use Recover.
+func RecordPanic(s Span, r interface{}) error {
+	s.AddEvent(
+		"exception",
+		"exception.type", fmt.Sprintf("%T", r),
+		"exception.message", fmt.Sprint(r),
+		"exception.stacktrace", string(debug.Stack()),
+	)
+	return errors.Errorf("panic: %v", r)
+}
+
+func endWithPanic(s Span, r interface{}) error {
+	err := RecordPanic(s, r)
+	s.End(err)
+	if sp, ok := s.(*span); ok {
+		// Deferred End will be called during panic, ignore it.
+		sp.endedWithPanic.Store(true)
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RetryPolicy configures Retry.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts. If <= 0, `f` is retried until it succeeds or context is done.
+	MaxAttempts int
+	// InitialBackoff is the delay before the second attempt. Defaults to 100ms.
+	InitialBackoff time.Duration
+	// MaxBackoff limits the delay between attempts. Defaults to 10s.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the delay grows with after each attempt. Defaults to 2.
+	Multiplier float64
+	// Jitter randomizes delays by up to the given fraction of the delay (e.g. 0.1 for ±10%). Values above 1 are
+	// treated as 1, so the delay is never negative.
+	Jitter float64
+	// Retryable decides if error is worth retrying. If nil, all errors are retried.
+	Retryable func(error) bool
+	// AttemptSpans makes Retry record each attempt as child span instead of an event.
+	AttemptSpans bool
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d, max, mul := p.InitialBackoff, p.MaxBackoff, p.Multiplier
+	if d <= 0 {
+		d = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+	if mul <= 0 {
+		mul = 2
+	}
+	for i := 1; i < attempt && d < max; i++ {
+		d = time.Duration(float64(d) * mul)
+	}
+	if d > max {
+		d = max
+	}
+	if p.Jitter > 0 {
+		d += time.Duration(math.Min(p.Jitter, 1) * float64(d) * (2*rand.Float64() - 1))
+	}
+	return d
+}
+
+// Retry does `f` function inside span, retrying it according to the given policy until it succeeds. Each attempt is
+// recorded as "attempt" event (or child span with policy.AttemptSpans) with the attempt number, delay before the
+// attempt and error (if any). The span is ended with the final outcome and the number of attempts as "retry.attempts"
+// attribute. The last error from `f` is returned unchanged, unless ctx is done while waiting for the next attempt. In
+// that case the last error is wrapped with ctx.Err() message; use ctx.Err() to check the cause.
+// WARNING: ctx has to be chained to root Tracer.StartSpan or Tracer.DoInSpan, or carry tracer from ContextWithTracer.
+// Otherwise, span is not recorded.
+func Retry(ctx context.Context, spanName string, policy RetryPolicy, f func(context.Context) error) error {
+	return DoInSpan(ctx, spanName, func(ctx context.Context) error {
+		span := GetSpan(ctx)
+
+		var (
+			attempt int
+			delay   time.Duration
+		)
+		defer func() { span.SetAttributes("retry.attempts", attempt) }()
+
+		for attempt = 1; ; attempt++ {
+			err := policy.attempt(ctx, span, attempt, delay, f)
+			if err == nil {
+				return nil
+			}
+			if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+				return err
+			}
+			if policy.Retryable != nil && !policy.Retryable(err) {
+				return err
+			}
+
+			delay = policy.backoff(attempt)
+			t := time.NewTimer(delay)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return errors.Wrapf(err, "%v after %s", ctx.Err(), attempts(attempt))
+			}
+		}
+	})
+}
+
+func attempts(n int) string {
+	if n == 1 {
+		return "1 attempt"
+	}
+	return fmt.Sprintf("%d attempts", n)
+}
+
+func (p RetryPolicy) attempt(ctx context.Context, span Span, attempt int, delay time.Duration, f func(context.Context) error) error {
+	if p.AttemptSpans {
+		return DoInSpan(ctx, "attempt", f, WithStartSpanAttributes("attempt", attempt, "delay", delay))
+	}
+
+	err := f(ctx)
+	if err != nil {
+		span.AddEvent("attempt", "attempt", attempt, "delay", delay, "error", err)
+		return err
+	}
+	span.AddEvent("attempt", "attempt", attempt, "delay", delay)
+	return nil
+}
@@ -0,0 +1,100 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestRetry(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t)
+	ctx, root := tr.StartSpan("root")
+
+	attempts := 0
+	testutil.Ok(t, tracing.Retry(ctx, "succeeding", tracing.RetryPolicy{InitialBackoff: time.Millisecond}, func(context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("fail")
+		}
+		return nil
+	}))
+
+	errFail := errors.New("fail")
+	err := tracing.Retry(ctx, "failing", tracing.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, AttemptSpans: true}, func(context.Context) error {
+		return errFail
+	})
+	testutil.Equals(t, errFail, err)
+
+	err = tracing.Retry(ctx, "non-retryable", tracing.RetryPolicy{Retryable: func(error) bool { return false }}, func(context.Context) error {
+		return errFail
+	})
+	testutil.Equals(t, errFail, err)
+	root.End(nil)
+
+	spans := rec.Spans()
+	testutil.Equals(t, 6, len(spans))
+
+	succeeding := spans[0]
+	testutil.Equals(t, "succeeding", succeeding.Name)
+	testutil.Equals(t, "ok", succeeding.Status)
+	testutil.Equals(t, 3, len(succeeding.Events))
+	for i, e := range succeeding.Events {
+		testutil.Equals(t, "attempt", e.Name)
+		if i < 2 {
+			testutil.Equals(t, 3, len(e.Attributes))
+			testutil.Equals(t, "fail", e.Attributes["error"])
+		}
+	}
+	testutil.Equals(t, "0s", succeeding.Events[0].Attributes["delay"])
+	testutil.Equals(t, "1ms", succeeding.Events[1].Attributes["delay"])
+	testutil.Equals(t, "2ms", succeeding.Events[2].Attributes["delay"])
+	testutil.Equals(t, "3", succeeding.Attributes["retry.attempts"])
+
+	for _, s := range spans[1:3] {
+		testutil.Equals(t, "attempt", s.Name)
+		testutil.Equals(t, spans[3].SpanID, s.ParentSpanID)
+		testutil.Equals(t, "error: fail", s.Status)
+	}
+	testutil.Equals(t, "failing", spans[3].Name)
+	testutil.Equals(t, "error: fail", spans[3].Status)
+	testutil.Equals(t, "non-retryable", spans[4].Name)
+}
+
+func TestRetry_ContextDone(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t)
+
+	errFail := errors.New("fail")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := tr.DoInSpan("root", func(ctx context.Context) error {
+		return tracing.Retry(ctx, "retry", tracing.RetryPolicy{InitialBackoff: time.Hour}, func(context.Context) error {
+			return errFail
+		})
+	}, tracing.WithTracerStartSpanContext(ctx))
+	testutil.Assert(t, errors.Is(err, errFail), err)
+	testutil.Equals(t, "context deadline exceeded after 1 attempt: fail", err.Error())
+
+	spans := rec.Spans()
+	testutil.Equals(t, "retry", spans[0].Name)
+	testutil.Equals(t, "1", spans[0].Attributes["retry.attempts"])
+}
+
+func TestRetry_JitterAboveOne(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t)
+	ctx, root := tr.StartSpan("root")
+	_ = tracing.Retry(ctx, "retry", tracing.RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond, Jitter: 5}, func(context.Context) error {
+		return errors.New("fail")
+	})
+	root.End(nil)
+
+	for _, e := range rec.Spans()[0].Events {
+		d, err := time.ParseDuration(e.Attributes["delay"])
+		testutil.Ok(t, err)
+		testutil.Assert(t, d >= 0, "negative delay %v", d)
+	}
+}
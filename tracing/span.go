@@ -2,32 +2,123 @@ package tracing
 
 import (
 	"context"
+	"fmt"
+	"sync/atomic"
 
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
 
 const instrumentationID = "tracing-go"
 
+// StartSpanOption sets the value in startSpanOptions.
+type StartSpanOption func(*startSpanOptions)
+
+type startSpanOptions struct {
+	attrs []attribute.KeyValue
+	kind  SpanKind
+	links []trace.Link
+}
+
+func (o startSpanOptions) otelOpts() []trace.SpanStartOption {
+	var opts []trace.SpanStartOption
+	if len(o.attrs) > 0 {
+		opts = append(opts, trace.WithAttributes(o.attrs...))
+	}
+	if o.kind != SpanKindUnspecified {
+		opts = append(opts, trace.WithSpanKind(o.kind))
+	}
+	if len(o.links) > 0 {
+		opts = append(opts, trace.WithLinks(o.links...))
+	}
+	return opts
+}
+
+// WithStartSpanAttributes sets attributes of the started span. Attributes are available to the sampler.
+func WithStartSpanAttributes(keyvals ...interface{}) StartSpanOption {
+	return func(o *startSpanOptions) {
+		o.attrs = append(o.attrs, kvToAttr(keyvals...)...)
+	}
+}
+
+// WithStartSpanKind sets the kind of the started span e.g. SpanKindClient. By default, it's SpanKindInternal.
+func WithStartSpanKind(kind SpanKind) StartSpanOption {
+	return func(o *startSpanOptions) {
+		o.kind = kind
+	}
+}
+
+// WithStartSpanLinks links the started span with spans identified by the given contexts, e.g. to relate the span
+// with the span of the producer of the processed message, without making it a parent. Invalid contexts are ignored.
+func WithStartSpanLinks(links ...Context) StartSpanOption {
+	return func(o *startSpanOptions) {
+		for _, l := range links {
+			if sc := spanContextFrom(l); sc.IsValid() {
+				o.links = append(o.links, trace.Link{SpanContext: sc})
+			}
+		}
+	}
+}
+
 // StartSpan creates spans using tracer in the context.
-// WARNING: ctx has to be chained to root Tracer.StartSpan or Tracer.DoInSpan.
-func StartSpan(ctx context.Context, spanName string) (context.Context, Span) {
-	sctx, s := trace.SpanFromContext(ctx).TracerProvider().Tracer(instrumentationID).Start(ctx, spanName)
-	return sctx, &span{Span: s}
+// WARNING: ctx has to be chained to root Tracer.StartSpan or Tracer.DoInSpan, or carry tracer from ContextWithTracer.
+// Otherwise, span is not recorded.
+func StartSpan(ctx context.Context, spanName string, opts ...StartSpanOption) (context.Context, Span) {
+	o := startSpanOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	tr := tracerFromContext(ctx)
+	if tr == nil {
+		reportOrphan(ctx, spanName)
+
+		sctx, s := trace.SpanFromContext(ctx).TracerProvider().Tracer(instrumentationID).Start(ctx, spanName, o.otelOpts()...)
+		return sctx, &span{Span: s}
+	}
+	sctx, s := tr.tr.Tracer(instrumentationID).Start(ctx, spanName, tr.otelOpts(o)...)
+	return sctx, newSpan(tr, sctx, spanName, s)
 }
 
 // DoInSpan does `f` function inside span using tracer in the context.
-// WARNING: ctx has to be chained to root Tracer.StartSpan or Tracer.DoInSpan.
-func DoInSpan(ctx context.Context, spanName string, f func(context.Context) error) error {
-	sctx, s := StartSpan(ctx, spanName)
+// If `f` panics, panic is recorded on the span (see Recover) and re-panicked.
+// WARNING: ctx has to be chained to root Tracer.StartSpan or Tracer.DoInSpan, or carry tracer from ContextWithTracer.
+// Otherwise, span is not recorded.
+func DoInSpan(ctx context.Context, spanName string, f func(context.Context) error, opts ...StartSpanOption) error {
+	sctx, s := StartSpan(ctx, spanName, opts...)
+	defer Recover(s)
+
 	err := f(sctx)
 	s.End(err)
 	return err
 }
 
+// DoInSpanT does `f` function that returns value inside span using tracer in the context.
+// If `f` panics, panic is recorded on the span (see Recover) and re-panicked.
+// WARNING: ctx has to be chained to root Tracer.StartSpan or Tracer.DoInSpan, or carry tracer from ContextWithTracer.
+// Otherwise, span is not recorded.
+func DoInSpanT[T any](ctx context.Context, spanName string, f func(context.Context) (T, error), opts ...StartSpanOption) (T, error) {
+	sctx, s := StartSpan(ctx, spanName, opts...)
+	defer Recover(s)
+
+	ret, err := f(sctx)
+	s.End(err)
+	return ret, err
+}
+
 // GetSpan returns current span or noopSpan if no span was created.
 func GetSpan(ctx context.Context) Span {
-	return &span{Span: trace.SpanFromContext(ctx)}
+	sp := &span{Span: trace.SpanFromContext(ctx)}
+	if tr := tracerFromContext(ctx); tr != nil {
+		sp.clock = tr.clock
+		if tr.strict != nil {
+			tr.strict.attach(sp)
+		}
+	}
+	return sp
 }
 
 // Span is the individual component of a trace. It represents a single named
@@ -43,7 +134,8 @@ type Span interface {
 	End(err error)
 
 	// Context returns span context that contains useful information about span and belonging trace.
-	// This information is available even after span End.
+	// This information is available even after span End and for not sampled spans (check Context.IsSampled), so
+	// the trace can be continued e.g. with TraceParent.
 	// NOTE: Do not confuse with Go context.Context which is important, but has to be tracked outside of Span.
 	Context() Context
 
@@ -59,45 +151,138 @@ type Span interface {
 
 type span struct {
 	trace.Span
+
+	endedWithPanic atomic.Bool
+	// stopTracking is set only with WithContextTracking.
+	stopTracking func()
+	// clock is set only with WithClock.
+	clock Clock
+
+	// Fields below are set only in strict mode.
+	strict *strictMode
+	name   string
+	stack  string
+}
+
+func newSpan(tr *Tracer, ctx context.Context, name string, s trace.Span) *span {
+	sp := &span{Span: s, clock: tr.clock}
+	if tr.strict != nil {
+		sp.strict = tr.strict
+		sp.name = name
+		tr.strict.started(sp)
+	}
+	if tr.trackContext {
+		sp.trackContext(ctx)
+	}
+	return sp
 }
 
 func (s *span) End(err error) {
+	if s.endedWithPanic.Load() {
+		return
+	}
+	if s.strict != nil && !s.strict.ended(s) {
+		return
+	}
+	if s.stopTracking != nil {
+		s.stopTracking()
+	}
 	if err != nil {
 		s.Span.SetStatus(codes.Error, err.Error())
 	} else {
 		s.Span.SetStatus(codes.Ok, "")
 	}
+	if s.clock != nil {
+		s.Span.End(trace.WithTimestamp(s.clock.Now()))
+		return
+	}
 	s.Span.End()
 }
 
-func (s *span) Context() Context {
-	sctx := s.SpanContext()
-	if !sctx.IsSampled() {
-		return ctx{}
-	}
-	return ctx{
-		traceID: sctx.TraceID().String(),
-		spanID:  sctx.SpanID().String(),
-	}
+func (s *span) Context() Context { return ctx{sc: s.SpanContext()} }
 
-}
 func (s *span) AddEvent(name string, keyvals ...interface{}) {
-	s.Span.AddEvent(name, trace.WithAttributes(kvToAttr(keyvals...)...))
+	opts := []trace.EventOption{trace.WithAttributes(kvToAttr(keyvals...)...)}
+	if s.clock != nil {
+		opts = append(opts, trace.WithTimestamp(s.clock.Now()))
+	}
+	s.Span.AddEvent(name, opts...)
 }
 
 func (s *span) SetAttributes(keyvals ...interface{}) { s.Span.SetAttributes(kvToAttr(keyvals...)...) }
 
+// Context represents span context that identifies span (and trace) and holds information propagated to the child spans.
 type Context interface {
 	IsSampled() bool
 	TraceID() string
 	SpanID() string
+
+	// TraceFlags returns W3C trace flags (e.g. 0x01 for sampled).
+	TraceFlags() byte
+	// TraceState returns W3C tracestate header value.
+	TraceState() string
+	// IsRemote returns true if this span context was propagated from the remote process.
+	IsRemote() bool
+	// TraceParent returns W3C traceparent header value e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	// or empty string if span context is invalid.
+	TraceParent() string
 }
 
 type ctx struct {
-	traceID string
-	spanID  string
+	sc trace.SpanContext
+}
+
+func (c ctx) IsSampled() bool { return c.sc.IsSampled() }
+
+func (c ctx) TraceID() string {
+	if !c.sc.HasTraceID() {
+		return ""
+	}
+	return c.sc.TraceID().String()
+}
+
+func (c ctx) SpanID() string {
+	if !c.sc.HasSpanID() {
+		return ""
+	}
+	return c.sc.SpanID().String()
+}
+
+func (c ctx) TraceFlags() byte   { return byte(c.sc.TraceFlags()) }
+func (c ctx) TraceState() string { return c.sc.TraceState().String() }
+func (c ctx) IsRemote() bool     { return c.sc.IsRemote() }
+
+func (c ctx) TraceParent() string {
+	if !c.sc.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-%s", c.sc.TraceID(), c.sc.SpanID(), c.sc.TraceFlags())
 }
 
-func (c ctx) IsSampled() bool { return c.traceID != "" }
-func (c ctx) TraceID() string { return c.traceID }
-func (c ctx) SpanID() string  { return c.spanID }
+// ParseTraceParent parses W3C traceparent value (e.g. stored in database or passed through CLI flag) into remote
+// span Context. It can be used as a parent of a new span with WithTracerStartSpanParent.
+func ParseTraceParent(traceParent string) (Context, error) {
+	pctx := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier{traceParentHeader: traceParent})
+	sc := trace.SpanContextFromContext(pctx)
+	if !sc.IsValid() {
+		return nil, errors.Errorf("invalid traceparent %q", traceParent)
+	}
+	return ctx{sc: sc}, nil
+}
+
+const (
+	traceParentHeader = "traceparent"
+	traceStateHeader  = "tracestate"
+)
+
+// spanContextFrom returns OpenTelemetry span context from the given Context, also from custom implementations.
+func spanContextFrom(c Context) trace.SpanContext {
+	if c, ok := c.(ctx); ok {
+		return c.sc
+	}
+	pctx := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier{
+		traceParentHeader: c.TraceParent(),
+		traceStateHeader:  c.TraceState(),
+	})
+	return trace.SpanContextFromContext(pctx).WithRemote(c.IsRemote())
+}
@@ -0,0 +1,157 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	for _, invalid := range []string{"", "00-4bf92f3577b34da6a3ce929d0e0e4736", "00-00000000000000000000000000000000-00f067aa0ba902b7-01"} {
+		_, err := tracing.ParseTraceParent(invalid)
+		testutil.NotOk(t, err)
+	}
+
+	parent, err := tracing.ParseTraceParent(traceParent)
+	testutil.Ok(t, err)
+	testutil.Equals(t, traceParent, parent.TraceParent())
+	testutil.Equals(t, "4bf92f3577b34da6a3ce929d0e0e4736", parent.TraceID())
+	testutil.Equals(t, "00f067aa0ba902b7", parent.SpanID())
+	testutil.Equals(t, byte(0x01), parent.TraceFlags())
+	testutil.Assert(t, parent.IsSampled())
+	testutil.Assert(t, parent.IsRemote())
+
+	tr, rec := tracingtest.NewTracer(t)
+	_, span := tr.StartSpan("child", tracing.WithTracerStartSpanParent(parent))
+	span.End(nil)
+	testutil.Equals(t, parent.TraceID(), span.Context().TraceID())
+	testutil.Assert(t, !span.Context().IsRemote())
+
+	spans := rec.Spans()
+	testutil.Equals(t, 1, len(spans))
+	testutil.Equals(t, parent.SpanID(), spans[0].ParentSpanID)
+}
+
+func TestSpanContext_NotSampled(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t, tracing.WithSampler(tracing.TraceIDRatioBasedSampler(0)))
+	_, span := tr.StartSpan("not sampled")
+	span.End(nil)
+	testutil.Equals(t, 0, len(rec.Spans()))
+
+	sctx := span.Context()
+	testutil.Assert(t, !sctx.IsSampled())
+	testutil.Equals(t, 32, len(sctx.TraceID()))
+	testutil.Equals(t, 16, len(sctx.SpanID()))
+	testutil.Equals(t, "00-"+sctx.TraceID()+"-"+sctx.SpanID()+"-00", sctx.TraceParent())
+
+	// Trace can be continued from the traceparent of the not sampled span, e.g. by the other service.
+	parent, err := tracing.ParseTraceParent(sctx.TraceParent())
+	testutil.Ok(t, err)
+	otherTr, otherRec := tracingtest.NewTracer(t)
+	_, child := otherTr.StartSpan("child", tracing.WithTracerStartSpanParent(parent))
+	child.End(nil)
+
+	spans := otherRec.Spans()
+	testutil.Equals(t, 1, len(spans))
+	testutil.Equals(t, sctx.TraceID(), spans[0].TraceID)
+	testutil.Equals(t, sctx.SpanID(), spans[0].ParentSpanID)
+}
+
+func TestDoInSpanT(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t)
+
+	ret, err := tracing.TracerDoInSpanT(tr, "root", func(ctx context.Context) (int, error) {
+		return tracing.DoInSpanT(ctx, "child", func(ctx context.Context) (int, error) {
+			return 42, nil
+		}, tracing.WithStartSpanKind(tracing.SpanKindClient), tracing.WithStartSpanAttributes("key", "value"))
+	}, tracing.WithTracerStartSpanKind(tracing.SpanKindServer))
+	testutil.Ok(t, err)
+	testutil.Equals(t, 42, ret)
+
+	_, err = tracing.TracerDoInSpanT(tr, "failed", func(ctx context.Context) (string, error) {
+		return "", errors.New("fail")
+	})
+	testutil.NotOk(t, err)
+
+	spans := rec.Spans()
+	testutil.Equals(t, 3, len(spans))
+	testutil.Equals(t, "child", spans[0].Name)
+	testutil.Equals(t, tracing.SpanKindClient, spans[0].Kind)
+	testutil.Equals(t, map[string]string{"key": "value"}, spans[0].Attributes)
+	testutil.Equals(t, "root", spans[1].Name)
+	testutil.Equals(t, tracing.SpanKindServer, spans[1].Kind)
+	testutil.Equals(t, "error: fail", spans[2].Status)
+}
+
+func TestDoInSpan_Panic(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t, tracing.WithStrictMode(tracing.PanicOnViolation))
+
+	func() {
+		defer func() { testutil.Equals(t, "boom", recover()) }()
+
+		_ = tr.DoInSpan("root", func(ctx context.Context) error {
+			_, span := tracing.StartSpan(ctx, "child")
+			defer span.End(nil)
+			defer tracing.Recover(span)
+
+			panic("boom")
+		})
+	}()
+
+	var err error
+	func() {
+		defer tracing.RecoverToError(tracing.GetSpan(context.Background()), &err)
+		panic("boom2")
+	}()
+	testutil.NotOk(t, err)
+	testutil.Equals(t, "panic: boom2", err.Error())
+
+	spans := rec.Spans()
+	testutil.Equals(t, 2, len(spans))
+	for i, name := range []string{"child", "root"} {
+		testutil.Equals(t, name, spans[i].Name)
+		testutil.Equals(t, "error: panic: boom", spans[i].Status)
+		testutil.Equals(t, 1, len(spans[i].Events))
+		testutil.Equals(t, "exception", spans[i].Events[0].Name)
+		testutil.Equals(t, 3, len(spans[i].Events[0].Attributes))
+		testutil.Equals(t, "string", spans[i].Events[0].Attributes["exception.type"])
+		testutil.Equals(t, "boom", spans[i].Events[0].Attributes["exception.message"])
+	}
+}
+
+func TestWithContextTracking(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t, tracing.WithContextTracking())
+
+	pctx, cancel := context.WithCancelCause(context.Background())
+	ctx, root := tr.StartSpan("root", tracing.WithTracerStartSpanContext(pctx))
+
+	dctx, dcancel := context.WithTimeout(ctx, time.Hour)
+	_, child := tracing.StartSpan(dctx, "child")
+	child.End(nil)
+	dcancel()
+
+	cancel(errors.New("client went away"))
+	root.End(nil)
+
+	spans := rec.Spans()
+	testutil.Equals(t, 2, len(spans))
+	testutil.Equals(t, "child", spans[0].Name)
+	testutil.Equals(t, 0, len(spans[0].Events))
+	testutil.Equals(t, 1, len(spans[0].Attributes))
+	remaining, err := time.ParseDuration(spans[0].Attributes["ctx.deadline.remaining"])
+	testutil.Ok(t, err)
+	testutil.Assert(t, remaining > 59*time.Minute && remaining <= time.Hour, "unexpected remaining time %v", remaining)
+
+	testutil.Equals(t, "root", spans[1].Name)
+	testutil.Equals(t, 0, len(spans[1].Attributes))
+	testutil.Equals(t, 1, len(spans[1].Events))
+	testutil.Equals(t, "context done", spans[1].Events[0].Name)
+	testutil.Equals(t, "client went away", spans[1].Events[0].Attributes["cause"])
+}
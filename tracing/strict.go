@@ -0,0 +1,166 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ViolationKind is the kind of tracing API misuse detected in the strict mode.
+type ViolationKind string
+
+const (
+	// OrphanContextViolation is reported when span is started from the context that carries the span of the Tracer,
+	// but is not chained to the Tracer itself (e.g. context created with OpenTelemetry API), so Tracer options like
+	// strict mode or clock are not applied to the span.
+	OrphanContextViolation ViolationKind = "span started from context without tracer"
+	// DoubleEndViolation is reported when span is ended more than once.
+	DoubleEndViolation ViolationKind = "span ended more than once"
+	// NotEndedViolation is reported on Tracer close for every span that was started, but never ended.
+	NotEndedViolation ViolationKind = "span not ended"
+)
+
+// Violation represents the tracing API misuse detected in the strict mode.
+type Violation struct {
+	Kind     ViolationKind
+	SpanName string
+	// Stack is the stack trace of the span creation.
+	Stack string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("tracing: %s: %q created at:\n%s", v.Kind, v.SpanName, v.Stack)
+}
+
+// WithStrictMode enables detection of the tracing API misuse. Every misuse is reported as Violation to the onViolation
+// callback, including spans started but never ended, which are reported on Tracer close.
+// Spans started from the context without tracer are reported to this Tracer, if the context carries the span of it
+// (e.g. span started by OpenTelemetry instrumentation). If the context does not carry span of any Tracer (e.g.
+// context.Background()), such span can't be attributed, so it's reported to all Tracers in strict mode.
+// Strict mode captures stack trace on each span creation, so it should be used only for debugging and tests.
+// Use PanicOnViolation as onViolation to panic on any misuse in tests.
+func WithStrictMode(onViolation func(Violation)) Option {
+	return func(o *options) {
+		o.onViolation = onViolation
+	}
+}
+
+// PanicOnViolation is WithStrictMode callback that panics on any violation.
+func PanicOnViolation(v Violation) {
+	panic(v.String())
+}
+
+type strictMode struct {
+	provider    *sdktrace.TracerProvider
+	onViolation func(Violation)
+
+	mu sync.Mutex
+	// open holds not ended spans by their ID, so span can be ended by any Span wrapping it (e.g. from GetSpan).
+	open map[trace.SpanID]openSpan
+}
+
+type openSpan struct {
+	name, stack string
+}
+
+func newStrictMode(provider *sdktrace.TracerProvider, onViolation func(Violation)) *strictMode {
+	s := &strictMode{provider: provider, onViolation: onViolation, open: map[trace.SpanID]openSpan{}}
+
+	strictModesMu.Lock()
+	strictModes[provider] = s
+	strictModesMu.Unlock()
+	return s
+}
+
+func (s *strictMode) started(sp *span) {
+	sp.stack = string(debug.Stack())
+
+	s.mu.Lock()
+	s.open[sp.SpanContext().SpanID()] = openSpan{name: sp.name, stack: sp.stack}
+	s.mu.Unlock()
+}
+
+// attach sets strict mode of the span (e.g. from GetSpan), if it was started by the Tracer of this strict mode.
+func (s *strictMode) attach(sp *span) {
+	if sp.TracerProvider() != s.provider {
+		return
+	}
+	sp.strict = s
+
+	s.mu.Lock()
+	o := s.open[sp.SpanContext().SpanID()]
+	s.mu.Unlock()
+	sp.name, sp.stack = o.name, o.stack
+}
+
+// ended returns false if span was already ended.
+func (s *strictMode) ended(sp *span) bool {
+	id := sp.SpanContext().SpanID()
+
+	s.mu.Lock()
+	if _, ok := s.open[id]; !ok {
+		s.mu.Unlock()
+		s.onViolation(Violation{Kind: DoubleEndViolation, SpanName: sp.name, Stack: sp.stack})
+		return false
+	}
+	delete(s.open, id)
+	s.mu.Unlock()
+	return true
+}
+
+func (s *strictMode) close() {
+	strictModesMu.Lock()
+	delete(strictModes, s.provider)
+	strictModesMu.Unlock()
+
+	s.mu.Lock()
+	notEnded := make([]Violation, 0, len(s.open))
+	for _, sp := range s.open {
+		notEnded = append(notEnded, Violation{Kind: NotEndedViolation, SpanName: sp.name, Stack: sp.stack})
+	}
+	s.open = map[trace.SpanID]openSpan{}
+	s.mu.Unlock()
+
+	sort.Slice(notEnded, func(i, j int) bool { return notEnded[i].SpanName < notEnded[j].SpanName })
+	for _, v := range notEnded {
+		s.onViolation(v)
+	}
+}
+
+// strictModes holds strict modes of not closed Tracers by their tracer provider, so spans started from context
+// without tracer can be reported. This is the only global state of this package and it's used only in strict mode.
+var (
+	strictModesMu sync.Mutex
+	strictModes   = map[*sdktrace.TracerProvider]*strictMode{}
+)
+
+// reportOrphan reports span started from the context without tracer to the strict mode of the Tracer, which started
+// the span in the context. If the span in the context was not started by any Tracer, it's reported to all strict modes.
+func reportOrphan(ctx context.Context, spanName string) {
+	var report []*strictMode
+
+	strictModesMu.Lock()
+	if provider, ok := trace.SpanFromContext(ctx).TracerProvider().(*sdktrace.TracerProvider); ok {
+		if s, ok := strictModes[provider]; ok {
+			report = append(report, s)
+		}
+	} else {
+		for _, s := range strictModes {
+			report = append(report, s)
+		}
+	}
+	strictModesMu.Unlock()
+
+	if len(report) == 0 {
+		return
+	}
+	v := Violation{Kind: OrphanContextViolation, SpanName: spanName, Stack: string(debug.Stack())}
+	for _, s := range report {
+		s.onViolation(v)
+	}
+}
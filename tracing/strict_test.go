@@ -0,0 +1,116 @@
+package tracing_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStrictMode(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		violations []tracing.Violation
+	)
+	rec := tracingtest.NewRecorder()
+	tr, closeFn, err := tracing.NewTracer(rec.Exporter(), tracing.WithSynchronousExport(), tracing.WithStrictMode(func(v tracing.Violation) {
+		mu.Lock()
+		violations = append(violations, v)
+		mu.Unlock()
+	}))
+	testutil.Ok(t, err)
+
+	ctx, root := tr.StartSpan("root")
+	_, _ = tracing.StartSpan(ctx, "not ended")
+	_, doubleEnded := tracing.StartSpan(ctx, "double ended")
+	doubleEnded.End(nil)
+	doubleEnded.End(nil)
+	// Context which lost the tracer, but carries the span of it (e.g. detached by OpenTelemetry API).
+	orphanCtx := trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+	_, orphan := tracing.StartSpan(orphanCtx, "orphan")
+	orphan.End(nil)
+	// Span from context without any span can't be attributed, so it's reported too.
+	_, orphan = tracing.StartSpan(context.Background(), "unattributed orphan")
+	orphan.End(nil)
+	root.End(nil)
+
+	testutil.Ok(t, closeFn())
+	testutil.Equals(t, 3, len(rec.Spans()))
+	testutil.Equals(t, 4, len(violations))
+	testutil.Equals(t, tracing.DoubleEndViolation, violations[0].Kind)
+	testutil.Equals(t, "double ended", violations[0].SpanName)
+	testutil.Assert(t, strings.Contains(violations[0].Stack, "TestStrictMode"), violations[0].Stack)
+	testutil.Equals(t, tracing.OrphanContextViolation, violations[1].Kind)
+	testutil.Equals(t, "orphan", violations[1].SpanName)
+	testutil.Equals(t, tracing.OrphanContextViolation, violations[2].Kind)
+	testutil.Equals(t, "unattributed orphan", violations[2].SpanName)
+	testutil.Equals(t, tracing.NotEndedViolation, violations[3].Kind)
+	testutil.Equals(t, "not ended", violations[3].SpanName)
+
+	// Closed tracer should not be notified anymore.
+	_, orphan = tracing.StartSpan(orphanCtx, "orphan")
+	orphan.End(nil)
+	_, orphan = tracing.StartSpan(context.Background(), "unattributed orphan")
+	orphan.End(nil)
+	testutil.Equals(t, 4, len(violations))
+}
+
+func TestStrictMode_EndFromGetSpan(t *testing.T) {
+	var violations []tracing.Violation
+	rec := tracingtest.NewRecorder()
+	tr, closeFn, err := tracing.NewTracer(rec.Exporter(), tracing.WithSynchronousExport(), tracing.WithStrictMode(func(v tracing.Violation) {
+		violations = append(violations, v)
+	}))
+	testutil.Ok(t, err)
+
+	ctx, root := tr.StartSpan("root")
+	tracing.GetSpan(ctx).End(nil)
+	testutil.Equals(t, 0, len(violations))
+
+	// Span ended by the other Span wrapping it is detected as ended.
+	root.End(nil)
+	testutil.Ok(t, closeFn())
+	testutil.Equals(t, 1, len(rec.Spans()))
+	testutil.Equals(t, 1, len(violations))
+	testutil.Equals(t, tracing.DoubleEndViolation, violations[0].Kind)
+	testutil.Equals(t, "root", violations[0].SpanName)
+}
+
+func TestStrictMode_OrphanReportedToOwner(t *testing.T) {
+	var otherViolations []tracing.Violation
+	_, _ = tracingtest.NewTracer(t, tracing.WithStrictMode(func(v tracing.Violation) { otherViolations = append(otherViolations, v) }))
+
+	var violations []tracing.Violation
+	tr, _ := tracingtest.NewTracer(t, tracing.WithStrictMode(func(v tracing.Violation) { violations = append(violations, v) }))
+
+	ctx, root := tr.StartSpan("root")
+	_, orphan := tracing.StartSpan(trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx)), "orphan")
+	orphan.End(nil)
+	// Unattributed orphan is reported to all tracers.
+	_, orphan = tracing.StartSpan(context.Background(), "unattributed orphan")
+	orphan.End(nil)
+	root.End(nil)
+
+	testutil.Equals(t, 2, len(violations))
+	testutil.Equals(t, "orphan", violations[0].SpanName)
+	testutil.Equals(t, "unattributed orphan", violations[1].SpanName)
+	testutil.Equals(t, 1, len(otherViolations))
+	testutil.Equals(t, tracing.OrphanContextViolation, otherViolations[0].Kind)
+	testutil.Equals(t, "unattributed orphan", otherViolations[0].SpanName)
+}
+
+func TestStrictMode_PanicOnViolation(t *testing.T) {
+	tr, _ := tracingtest.NewTracer(t, tracing.WithStrictMode(tracing.PanicOnViolation))
+
+	_, span := tr.StartSpan("root")
+	span.End(nil)
+	defer func() {
+		testutil.Assert(t, recover() != nil)
+	}()
+	span.End(nil)
+}
@@ -37,6 +37,13 @@ type options struct {
 	newExporterFns []ExporterBuilder
 	sampler        Sampler
 	svcName        string
+	propagators    []Propagator
+	baggageAttrs   []string
+	onViolation    func(Violation)
+	trackContext   bool
+	syncExport     bool
+	clock          Clock
+	idGenerator    IDGenerator
 }
 
 // WithExporter sets additional exporter builders for spans. E.g. otlp.Exporter and Thrift
@@ -47,12 +54,39 @@ func WithExporter(startExporterFn ExporterBuilder) Option {
 }
 
 // WithSampler sets sampler, by default there is no sampler.
+// NOTE: Spans created from context with ContextWithDebug are always sampled, regardless of the sampler.
 func WithSampler(s Sampler) Option {
 	return func(o *options) {
 		o.sampler = s
 	}
 }
 
+// WithPropagators sets propagators used by all instrumentation (e.g. tracinghttp) to inject and extract trace context
+// to and from the carriers like HTTP headers. On injection, all propagators are used. On extraction, all propagators
+// are tried and the first one (in given order) that finds trace context wins.
+// By default, TraceContextPropagator and BaggagePropagator are used.
+func WithPropagators(p ...Propagator) Option {
+	return func(o *options) {
+		o.propagators = append(o.propagators, p...)
+	}
+}
+
+// WithBaggageAttributes sets baggage keys (see SetBaggage), which values will be copied as attributes
+// on every span created by this tracer (if present in the context).
+func WithBaggageAttributes(keys ...string) Option {
+	return func(o *options) {
+		o.baggageAttrs = append(o.baggageAttrs, keys...)
+	}
+}
+
+// WithSynchronousExport makes tracer export each span synchronously when it's ended, instead of in batches.
+// It's slow, so use it only for testing or debugging.
+func WithSynchronousExport() Option {
+	return func(o *options) {
+		o.syncExport = true
+	}
+}
+
 // TraceIDRatioBasedSampler samples a given fraction of traces. Fractions >= 1 will
 // always sample. Fractions < 0 are treated as zero. To respect the
 // parent trace's `SampledFlag`, the `TraceIDRatioBased` sampler should be used
@@ -73,11 +107,17 @@ func WithServiceName(s string) Option {
 // Tracer is the root tracing entity that can enables creation
 // of spans, and its export to the desired backends in a form of traces.
 type Tracer struct {
-	tr trace.TracerProvider
+	tr         *sdktrace.TracerProvider
+	propagator Propagator
+	strict     *strictMode
+
+	trackContext bool
+	clock        Clock
 }
 
 // NewTracer creates new instance of Tracer with given exporter builder.
-// Tracer returns tracer and close function that releases all resources or error.
+// Tracer returns tracer and close function that releases all resources or error. Close function exports all spans
+// still pending in batches, before it shuts down the exporters.
 func NewTracer(exporter ExporterBuilder, opts ...Option) (*Tracer, func() error, error) {
 	o := options{
 		newExporterFns: []ExporterBuilder{exporter},
@@ -86,6 +126,7 @@ func NewTracer(exporter ExporterBuilder, opts ...Option) (*Tracer, func() error,
 		opt(&o)
 	}
 
+	// closers shutdown already created exporters, in case of the error.
 	var closers []func() error
 	closeFn := func() error {
 		errs := merrors.New()
@@ -109,6 +150,12 @@ func NewTracer(exporter ExporterBuilder, opts ...Option) (*Tracer, func() error,
 		// TODO(bwplotka): Detect process info etc.
 		sdktrace.WithResource(resource.NewSchemaless(attribute.KeyValue{Key: "service.name" /*semconv.ServiceNameKey*/, Value: attribute.StringValue(svcName)})),
 	}
+	if o.idGenerator != nil {
+		tpOpts = append(tpOpts, sdktrace.WithIDGenerator(o.idGenerator))
+	}
+	if len(o.baggageAttrs) > 0 {
+		tpOpts = append(tpOpts, sdktrace.WithSpanProcessor(baggageAttrsProcessor{keys: o.baggageAttrs}))
+	}
 	for _, ne := range o.newExporterFns {
 		exporter, err := ne()
 		if err != nil {
@@ -121,26 +168,48 @@ func NewTracer(exporter ExporterBuilder, opts ...Option) (*Tracer, func() error,
 			return exporter.Shutdown(ctx)
 		})
 
+		if o.syncExport {
+			tpOpts = append(tpOpts, sdktrace.WithSyncer(exporter))
+			continue
+		}
 		// TODO(bwplotka): Allow different batch options too.
 		tpOpts = append(tpOpts, sdktrace.WithBatcher(exporter))
 	}
 
-	if o.sampler != nil {
-		tpOpts = append(tpOpts, sdktrace.WithSampler(o.sampler))
-	} else {
-		tpOpts = append(tpOpts, sdktrace.WithSampler(sdktrace.AlwaysSample()))
+	sampler := o.sampler
+	if sampler == nil {
+		sampler = sdktrace.AlwaysSample()
+	}
+	tpOpts = append(tpOpts, sdktrace.WithSampler(debugSampler{Sampler: sampler}))
+
+	propagator := defaultPropagators
+	if len(o.propagators) > 0 {
+		propagator = o.propagators
 	}
 
-	return &Tracer{
-		tr: sdktrace.NewTracerProvider(tpOpts...),
-	}, closeFn, nil
+	tr := &Tracer{tr: sdktrace.NewTracerProvider(tpOpts...), propagator: propagator, trackContext: o.trackContext, clock: o.clock}
+	if o.onViolation != nil {
+		tr.strict = newStrictMode(tr.tr, o.onViolation)
+	}
+	return tr, func() error {
+		if tr.strict != nil {
+			tr.strict.close()
+		}
+		// Shutdown of provider flushes all pending spans and shutdowns all exporters.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		return tr.tr.Shutdown(ctx)
+	}, nil
 }
 
 // TracerStartSpanOption sets the value in tracerStartSpanOptions.
 type TracerStartSpanOption func(*tracerStartSpanOptions)
 
 type tracerStartSpanOptions struct {
-	ctx context.Context
+	startSpanOptions
+
+	ctx    context.Context
+	parent Context
 }
 
 func WithTracerStartSpanContext(ctx context.Context) TracerStartSpanOption {
@@ -149,6 +218,28 @@ func WithTracerStartSpanContext(ctx context.Context) TracerStartSpanOption {
 	}
 }
 
+// WithTracerStartSpanParent sets the parent span context of the started span, e.g. one from ParseTraceParent.
+// It takes precedence over the span in the context set by WithTracerStartSpanContext.
+func WithTracerStartSpanParent(parent Context) TracerStartSpanOption {
+	return func(spanOptions *tracerStartSpanOptions) {
+		spanOptions.parent = parent
+	}
+}
+
+// WithTracerStartSpanAttributes sets attributes of the started span. Attributes are available to the sampler.
+func WithTracerStartSpanAttributes(keyvals ...interface{}) TracerStartSpanOption {
+	return func(spanOptions *tracerStartSpanOptions) {
+		WithStartSpanAttributes(keyvals...)(&spanOptions.startSpanOptions)
+	}
+}
+
+// WithTracerStartSpanKind sets the kind of the started span e.g. SpanKindServer. By default, it's SpanKindInternal.
+func WithTracerStartSpanKind(kind SpanKind) TracerStartSpanOption {
+	return func(spanOptions *tracerStartSpanOptions) {
+		WithStartSpanKind(kind)(&spanOptions.startSpanOptions)
+	}
+}
+
 // StartSpan creates a new root span that can add more spans using returned context. Returned context
 func (tr *Tracer) StartSpan(spanName string, opts ...TracerStartSpanOption) (context.Context, Span) {
 	o := tracerStartSpanOptions{ctx: context.Background()}
@@ -156,15 +247,51 @@ func (tr *Tracer) StartSpan(spanName string, opts ...TracerStartSpanOption) (con
 	for _, opt := range opts {
 		opt(&o)
 	}
+	if o.parent != nil {
+		o.ctx = trace.ContextWithSpanContext(o.ctx, spanContextFrom(o.parent))
+	}
 
-	sctx, s := tr.tr.Tracer(instrumentationID).Start(o.ctx, spanName)
-	return sctx, &span{Span: s}
+	sctx, s := tr.tr.Tracer(instrumentationID).Start(o.ctx, spanName, tr.otelOpts(o.startSpanOptions)...)
+	return ContextWithTracer(sctx, tr), newSpan(tr, sctx, spanName, s)
+}
+
+func (tr *Tracer) otelOpts(o startSpanOptions) []trace.SpanStartOption {
+	opts := o.otelOpts()
+	if tr.clock != nil {
+		opts = append(opts, trace.WithTimestamp(tr.clock.Now()))
+	}
+	return opts
+}
+
+// WithContext returns context with this tracer, so StartSpan and DoInSpan can create spans from it.
+// See ContextWithTracer for details.
+func (tr *Tracer) WithContext(ctx context.Context) context.Context {
+	return ContextWithTracer(ctx, tr)
+}
+
+// Propagator returns propagator configured for this Tracer.
+func (tr *Tracer) Propagator() Propagator {
+	return tr.propagator
 }
 
 // DoInSpan does `f` function that can return error inside span using tracer in the context.
+// If `f` panics, panic is recorded on the span (see Recover) and re-panicked.
 func (tr *Tracer) DoInSpan(spanName string, f func(context.Context) error, opts ...TracerStartSpanOption) error {
 	sctx, s := tr.StartSpan(spanName, opts...)
+	defer Recover(s)
+
 	err := f(sctx)
 	s.End(err)
 	return err
 }
+
+// TracerDoInSpanT does `f` function that returns value inside span created by the given tracer.
+// It's generic equivalent of Tracer.DoInSpan (Go does not support generic methods).
+func TracerDoInSpanT[T any](tr *Tracer, spanName string, f func(context.Context) (T, error), opts ...TracerStartSpanOption) (T, error) {
+	sctx, s := tr.StartSpan(spanName, opts...)
+	defer Recover(s)
+
+	ret, err := f(sctx)
+	s.End(err)
+	return ret, err
+}
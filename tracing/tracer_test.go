@@ -0,0 +1,42 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type shutdownRecordingExporter struct {
+	*tracetest.InMemoryExporter
+
+	shutdowns int
+}
+
+func (e *shutdownRecordingExporter) Shutdown(context.Context) error {
+	e.shutdowns++
+	return nil
+}
+
+func TestNewTracer_CloseFlushesBatchedSpans(t *testing.T) {
+	exp1 := &shutdownRecordingExporter{InMemoryExporter: tracetest.NewInMemoryExporter()}
+	exp2 := &shutdownRecordingExporter{InMemoryExporter: tracetest.NewInMemoryExporter()}
+	tr, closeFn, err := tracing.NewTracer(
+		func() (tracing.Exporter, error) { return exp1, nil },
+		tracing.WithExporter(func() (tracing.Exporter, error) { return exp2, nil }),
+	)
+	testutil.Ok(t, err)
+
+	_, span := tr.StartSpan("root")
+	span.End(nil)
+	// Spans are batched, so they are not exported yet.
+	testutil.Equals(t, 0, len(exp1.GetSpans()))
+
+	testutil.Ok(t, closeFn())
+	for _, e := range []*shutdownRecordingExporter{exp1, exp2} {
+		testutil.Equals(t, 1, len(e.GetSpans()))
+		testutil.Equals(t, 1, e.shutdowns)
+	}
+}
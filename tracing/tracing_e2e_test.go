@@ -4,14 +4,16 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"os/exec"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/bwplotka/tracing-go/tracing"
 	"github.com/bwplotka/tracing-go/tracing/exporters/jaeger"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest/jaegerquery"
 	"github.com/efficientgo/e2e"
-	e2einteractive "github.com/efficientgo/e2e/interactive"
 	"github.com/efficientgo/tools/core/pkg/testutil"
 	"github.com/pkg/errors"
 )
@@ -48,13 +50,17 @@ func dummyOperation(ctx context.Context) (err error) {
 	return nil
 }
 
-func runInstrumentedApp(t *testing.T, jaegerEndpoint string) {
-	tr, closeFn, err := tracing.NewTracer(jaeger.Exporter(jaegerEndpoint), tracing.WithServiceName("app"))
-	testutil.Ok(t, err)
-	t.Cleanup(func() {
-		testutil.Ok(t, closeFn())
-	})
+// cheapOperation produces the same spans as dummyOperation, without the cost.
+func cheapOperation(ctx context.Context) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "dummy operation")
+	defer func() { span.End(err) }()
 
+	_ = tracing.DoInSpan(ctx, "sub operation1", func(context.Context) error { return nil })
+	_ = tracing.DoInSpan(ctx, "sub operation2", func(context.Context) error { return nil })
+	return nil
+}
+
+func runInstrumentedApp(tr *tracing.Tracer, op func(context.Context) error) {
 	ctx, root := tr.StartSpan("app")
 	defer root.End(nil)
 
@@ -62,15 +68,42 @@ func runInstrumentedApp(t *testing.T, jaegerEndpoint string) {
 	for i := 0; i < 10; i++ {
 		wg.Add(1)
 		go func() {
-			_ = dummyOperation(ctx)
+			_ = op(ctx)
 			wg.Done()
 		}()
 	}
 	wg.Wait()
 }
 
+func runInstrumentedAppWithJaeger(t *testing.T, jaegerEndpoint string) {
+	tr, closeFn, err := tracing.NewTracer(jaeger.Exporter(jaegerEndpoint), tracing.WithServiceName("app"))
+	testutil.Ok(t, err)
+
+	runInstrumentedApp(tr, dummyOperation)
+	// Flush all spans.
+	testutil.Ok(t, closeFn())
+}
+
+// expectedAppTree returns expected span tree produced by runInstrumentedApp.
+func expectedAppTree() tracingtest.Node {
+	op := tracingtest.Node{Name: "dummy operation", Children: []tracingtest.Node{{Name: "sub operation1"}, {Name: "sub operation2"}}}
+	app := tracingtest.Node{Name: "app", Status: "ok"}
+	for i := 0; i < 10; i++ {
+		app.Children = append(app.Children, op)
+	}
+	return app
+}
+
+// skipWithoutDocker skips the test if Docker daemon is not available.
+func skipWithoutDocker(t *testing.T) {
+	t.Helper()
+	if err := exec.Command("docker", "info").Run(); err != nil {
+		t.Skipf("requires Docker: %v", err)
+	}
+}
+
 func TestTracingOTLPWithJaeger(t *testing.T) {
-	t.Skip("interactive test, comment this out before running")
+	skipWithoutDocker(t)
 
 	e, err := e2e.NewDockerEnvironment("e2e_otlp")
 	testutil.Ok(t, err)
@@ -90,9 +123,28 @@ func TestTracingOTLPWithJaeger(t *testing.T) {
 
 	testutil.Ok(t, e2e.StartAndWaitReady(jaeger))
 
-	runInstrumentedApp(t, "http://"+jaeger.Endpoint("jaeger.thrift")+"/api/traces")
+	runInstrumentedAppWithJaeger(t, "http://"+jaeger.Endpoint("jaeger.thrift")+"/api/traces")
+
+	c := jaegerquery.NewClient("http://" + jaeger.Endpoint("http.front"))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// Spans are not queryable right away, wait for all of them.
+	var spans tracingtest.Spans
+	for len(spans) < 31 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for spans, got %d: %v", len(spans), err)
+		case <-time.After(500 * time.Millisecond):
+		}
+		spans, err = c.Traces(ctx, jaegerquery.Query{Service: "app"})
+	}
+	tracingtest.AssertTree(t, spans, expectedAppTree())
+}
+
+func TestInstrumentedApp(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t, tracing.WithServiceName("app"))
+	runInstrumentedApp(tr, cheapOperation)
 
-	// TODO(bwplotka): Make it non-interactive and expect certain Jaeger output.
-	testutil.Ok(t, e2einteractive.OpenInBrowser("http://"+jaeger.Endpoint("http.front")))
-	testutil.Ok(t, e2einteractive.RunUntilEndpointHit())
+	tracingtest.AssertTree(t, rec.Spans(), expectedAppTree())
 }
@@ -0,0 +1,28 @@
+package tracingtest
+
+import (
+	"sync"
+	"time"
+)
+
+// StepClock is a tracing.Clock that starts at the given time and advances by the given step on every Now call.
+type StepClock struct {
+	mu   sync.Mutex
+	now  time.Time
+	step time.Duration
+}
+
+// NewStepClock returns new StepClock.
+func NewStepClock(start time.Time, step time.Duration) *StepClock {
+	return &StepClock{now: start, step: step}
+}
+
+// Now returns current time of the clock and advances it.
+func (c *StepClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
@@ -0,0 +1,113 @@
+package tracingtest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+// UpdateEnvVar is the environment variable, which makes AssertGolden update golden files, when set to "1".
+// Package does not register its own flag, so it does not conflict with flags of test packages. Use WithUpdateFlag
+// to update golden files with the flag defined by the test package instead.
+const UpdateEnvVar = "TRACINGTEST_UPDATE"
+
+// RenderOption sets the value of an option for Render.
+type RenderOption func(*renderOptions)
+
+type renderOptions struct {
+	attrs  map[string]struct{}
+	masked map[string]struct{}
+	update *bool
+}
+
+// WithRenderedAttributes sets attribute keys to render. By default, no attributes are rendered.
+func WithRenderedAttributes(keys ...string) RenderOption {
+	return func(o *renderOptions) {
+		for _, k := range keys {
+			o.attrs[k] = struct{}{}
+		}
+	}
+}
+
+// WithMaskedAttributes sets attribute keys to render with masked value, e.g. for attributes with timings. It's
+// useful to check attribute presence without its value.
+func WithMaskedAttributes(keys ...string) RenderOption {
+	return func(o *renderOptions) {
+		for _, k := range keys {
+			o.masked[k] = struct{}{}
+		}
+	}
+}
+
+// WithUpdateFlag makes AssertGolden update golden files when the given flag is true, e.g.
+// `tracingtest.WithUpdateFlag(update)` with `var update = flag.Bool("update", false, "update golden files")` defined in
+// the test package. It's used by AssertGolden only, Render ignores it.
+func WithUpdateFlag(update *bool) RenderOption {
+	return func(o *renderOptions) {
+		o.update = update
+	}
+}
+
+// Render renders spans as a stable, normalized text tree, one tree per root span, ordered by start time (then name).
+// IDs and timings are omitted, so the output is the same across runs. Each line contains span name, status,
+// attributes selected with options and event names.
+func Render(spans Spans, opts ...RenderOption) string {
+	return render(spans, newRenderOptions(opts))
+}
+
+func newRenderOptions(opts []RenderOption) renderOptions {
+	o := renderOptions{attrs: map[string]struct{}{}, masked: map[string]struct{}{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func render(spans Spans, o renderOptions) string {
+	b := &strings.Builder{}
+	renderSpans(b, spans, spans.Roots().sorted(), o, 0)
+	return b.String()
+}
+
+func renderSpans(w *strings.Builder, all, spans Spans, o renderOptions, depth int) {
+	for _, sp := range spans {
+		attrs := map[string]string{}
+		for k, v := range sp.Attributes {
+			if _, ok := o.masked[k]; ok {
+				attrs[k] = "<masked>"
+				continue
+			}
+			if _, ok := o.attrs[k]; ok {
+				attrs[k] = v
+			}
+		}
+		var events []string
+		for _, ev := range sp.Events {
+			events = append(events, ev.Name)
+		}
+		renderLine(w, depth, sp.Name, sp.Status, attrs, events)
+		renderSpans(w, all, all.ChildrenOf(sp).sorted(), o, depth+1)
+	}
+}
+
+// AssertGolden asserts that spans rendered with Render are the same as the content of the golden file.
+// Run tests with TRACINGTEST_UPDATE=1 environment variable (or flag passed with WithUpdateFlag) to write rendered
+// spans to the golden file instead.
+func AssertGolden(t testing.TB, spans Spans, goldenFile string, opts ...RenderOption) {
+	t.Helper()
+
+	o := newRenderOptions(opts)
+	got := render(spans, o)
+	if os.Getenv(UpdateEnvVar) == "1" || (o.update != nil && *o.update) {
+		testutil.Ok(t, os.MkdirAll(filepath.Dir(goldenFile), 0o755))
+		testutil.Ok(t, os.WriteFile(goldenFile, []byte(got), 0o644))
+		return
+	}
+
+	exp, err := os.ReadFile(goldenFile)
+	testutil.Ok(t, err, "read golden file; run test with "+UpdateEnvVar+"=1 environment variable to create it")
+	testutil.Equals(t, string(exp), got)
+}
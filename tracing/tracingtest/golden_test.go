@@ -0,0 +1,62 @@
+package tracingtest_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestAssertGolden(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t, tracing.WithContextTracking())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	_ = tr.DoInSpan("handle request", func(ctx context.Context) error {
+		tracing.GetSpan(ctx).SetAttributes("user", "bwplotka", "request.id", time.Now().UnixNano())
+		_ = tracing.DoInSpan(ctx, "cache lookup", func(ctx context.Context) error {
+			tracing.GetSpan(ctx).AddEvent("cache miss")
+			return nil
+		})
+		return tracing.DoInSpan(ctx, "fetch", func(ctx context.Context) error {
+			return errors.New("not found")
+		})
+	}, tracing.WithTracerStartSpanContext(ctx))
+
+	opts := []tracingtest.RenderOption{
+		tracingtest.WithRenderedAttributes("user"),
+		tracingtest.WithMaskedAttributes("ctx.deadline.remaining"),
+	}
+	tracingtest.AssertGolden(t, rec.Spans(), "testdata/trace.golden", opts...)
+
+	// Rendered tree is stable across runs.
+	rec.Reset()
+	_ = tr.DoInSpan("handle request", func(ctx context.Context) error {
+		return nil
+	})
+	testutil.Equals(t, "handle request [ok]\n", tracingtest.Render(rec.Spans(), opts...))
+}
+
+func TestAssertGolden_UpdateFlag(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t)
+	_ = tr.DoInSpan("handle request", func(ctx context.Context) error {
+		return nil
+	})
+
+	goldenFile := filepath.Join(t.TempDir(), "trace.golden")
+	update := true
+	tracingtest.AssertGolden(t, rec.Spans(), goldenFile, tracingtest.WithUpdateFlag(&update))
+
+	b, err := os.ReadFile(goldenFile)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "handle request [ok]\n", string(b))
+
+	update = false
+	tracingtest.AssertGolden(t, rec.Spans(), goldenFile, tracingtest.WithUpdateFlag(&update))
+}
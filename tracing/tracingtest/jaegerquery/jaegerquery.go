@@ -0,0 +1,303 @@
+// Package jaegerquery provides minimal client of Jaeger HTTP query API (the one used by Jaeger UI), which converts
+// returned traces to tracingtest.Spans, so exported traces can be asserted in e2e tests.
+package jaegerquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/pkg/errors"
+)
+
+// Client is a Jaeger HTTP query API client.
+type Client struct {
+	endpoint string
+	client   *http.Client
+}
+
+// Option sets the value of an option for a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets HTTP client used for queries. By default, http.DefaultClient is used.
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) {
+		cl.client = c
+	}
+}
+
+// NewClient returns new Client for the Jaeger query endpoint e.g. "http://localhost:16686".
+func NewClient(endpoint string, opts ...Option) *Client {
+	c := &Client{endpoint: strings.TrimSuffix(endpoint, "/"), client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Query selects traces to find.
+type Query struct {
+	// Service is the name of the service. Required.
+	Service string
+	// Operation is the span name. Optional.
+	Operation string
+	// Tags are span tags (attributes) to match. Optional.
+	Tags map[string]string
+	// Start and End limit the time range of the search. Optional.
+	Start, End time.Time
+	// Limit is the maximum number of traces to return. Jaeger default is used if 0.
+	Limit int
+}
+
+func (q Query) values() (url.Values, error) {
+	v := url.Values{}
+	v.Set("service", q.Service)
+	if q.Operation != "" {
+		v.Set("operation", q.Operation)
+	}
+	if len(q.Tags) > 0 {
+		b, err := json.Marshal(q.Tags)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal tags")
+		}
+		v.Set("tags", string(b))
+	}
+	if !q.Start.IsZero() {
+		v.Set("start", strconv.FormatInt(q.Start.UnixMicro(), 10))
+	}
+	if !q.End.IsZero() {
+		v.Set("end", strconv.FormatInt(q.End.UnixMicro(), 10))
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return v, nil
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	} `json:"errors"`
+}
+
+func (c *Client) get(ctx context.Context, path string, values url.Values, data interface{}) error {
+	u := c.endpoint + path
+	if len(values) > 0 {
+		u += "?" + values.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return errors.Wrap(err, "create request")
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "get %v", u)
+	}
+	defer res.Body.Close()
+
+	r := response{}
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return errors.Wrapf(err, "decode response of %v with status %v", u, res.Status)
+	}
+	if len(r.Errors) > 0 {
+		return errors.Errorf("get %v: %v (code %d)", u, r.Errors[0].Msg, r.Errors[0].Code)
+	}
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("get %v: unexpected status %v", u, res.Status)
+	}
+	return errors.Wrap(json.Unmarshal(r.Data, data), "decode data")
+}
+
+// Services returns names of services known to Jaeger.
+func (c *Client) Services(ctx context.Context) ([]string, error) {
+	var services []string
+	if err := c.get(ctx, "/api/services", nil, &services); err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+// Traces returns spans of all traces matching the query.
+func (c *Client) Traces(ctx context.Context, q Query) (tracingtest.Spans, error) {
+	if q.Service == "" {
+		return nil, errors.New("service is required")
+	}
+	v, err := q.values()
+	if err != nil {
+		return nil, err
+	}
+
+	var traces []jaegerTrace
+	if err := c.get(ctx, "/api/traces", v, &traces); err != nil {
+		return nil, err
+	}
+	return toSpans(traces)
+}
+
+// Trace returns spans of the trace with the given ID.
+func (c *Client) Trace(ctx context.Context, traceID string) (tracingtest.Spans, error) {
+	var traces []jaegerTrace
+	if err := c.get(ctx, "/api/traces/"+url.PathEscape(traceID), nil, &traces); err != nil {
+		return nil, err
+	}
+	return toSpans(traces)
+}
+
+type jaegerTrace struct {
+	Spans     []jaegerSpan             `json:"spans"`
+	Processes map[string]jaegerProcess `json:"processes"`
+}
+
+type jaegerProcess struct {
+	ServiceName string      `json:"serviceName"`
+	Tags        []jaegerTag `json:"tags"`
+}
+
+type jaegerSpan struct {
+	TraceID       string `json:"traceID"`
+	SpanID        string `json:"spanID"`
+	OperationName string `json:"operationName"`
+	References    []struct {
+		RefType string `json:"refType"`
+		TraceID string `json:"traceID"`
+		SpanID  string `json:"spanID"`
+	} `json:"references"`
+	// StartTime and Duration are in microseconds.
+	StartTime int64       `json:"startTime"`
+	Duration  int64       `json:"duration"`
+	Tags      []jaegerTag `json:"tags"`
+	Logs      []struct {
+		Timestamp int64       `json:"timestamp"`
+		Fields    []jaegerTag `json:"fields"`
+	} `json:"logs"`
+	ProcessID string `json:"processID"`
+}
+
+type jaegerTag struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+func (t jaegerTag) value() string {
+	var s string
+	if err := json.Unmarshal(t.Value, &s); err == nil {
+		return s
+	}
+	return string(t.Value)
+}
+
+var spanKinds = map[string]tracing.SpanKind{
+	"internal": tracing.SpanKindInternal,
+	"server":   tracing.SpanKindServer,
+	"client":   tracing.SpanKindClient,
+	"producer": tracing.SpanKindProducer,
+	"consumer": tracing.SpanKindConsumer,
+}
+
+func toSpans(traces []jaegerTrace) (tracingtest.Spans, error) {
+	var spans tracingtest.Spans
+	for _, t := range traces {
+		for _, js := range t.Spans {
+			s, err := toSpan(js, t.Processes[js.ProcessID])
+			if err != nil {
+				return nil, err
+			}
+			spans = append(spans, s)
+		}
+	}
+	return spans, nil
+}
+
+// toSpan converts Jaeger span to tracingtest.Span. Process service name and tags are mapped to the resource attributes.
+func toSpan(js jaegerSpan, p jaegerProcess) (tracingtest.Span, error) {
+	traceID, err := normalizeID(js.TraceID, 32)
+	if err != nil {
+		return tracingtest.Span{}, err
+	}
+	spanID, err := normalizeID(js.SpanID, 16)
+	if err != nil {
+		return tracingtest.Span{}, err
+	}
+
+	start := time.UnixMicro(js.StartTime)
+	s := tracingtest.Span{
+		Name:       js.OperationName,
+		Kind:       tracing.SpanKindInternal,
+		TraceID:    traceID,
+		SpanID:     spanID,
+		Status:     "unset",
+		Attributes: map[string]string{},
+		Start:      start,
+		End:        start.Add(time.Duration(js.Duration) * time.Microsecond),
+	}
+	if p.ServiceName != "" || len(p.Tags) > 0 {
+		s.Resource = map[string]string{}
+		for _, t := range p.Tags {
+			s.Resource[t.Key] = t.value()
+		}
+		if p.ServiceName != "" {
+			s.Resource["service.name"] = p.ServiceName
+		}
+	}
+	for _, r := range js.References {
+		id, err := normalizeID(r.SpanID, 16)
+		if err != nil {
+			return tracingtest.Span{}, err
+		}
+		if r.RefType == "CHILD_OF" && s.ParentSpanID == "" {
+			s.ParentSpanID = id
+			continue
+		}
+		s.LinkedSpanIDs = append(s.LinkedSpanIDs, id)
+	}
+
+	var statusDesc string
+	for _, t := range js.Tags {
+		switch t.Key {
+		case "span.kind":
+			if k, ok := spanKinds[t.value()]; ok {
+				s.Kind = k
+			}
+		case "otel.status_code":
+			s.Status = strings.ToLower(t.value())
+		case "otel.status_description":
+			statusDesc = t.value()
+		case "error", "otel.library.name", "otel.library.version", "internal.span.format":
+		default:
+			s.Attributes[t.Key] = t.value()
+		}
+	}
+	if s.Status == "error" {
+		s.Status = "error: " + statusDesc
+	}
+
+	for _, l := range js.Logs {
+		e := tracingtest.Event{Attributes: map[string]string{}, Time: time.UnixMicro(l.Timestamp)}
+		for _, f := range l.Fields {
+			if f.Key == "event" {
+				e.Name = f.value()
+				continue
+			}
+			e.Attributes[f.Key] = f.value()
+		}
+		s.Events = append(s.Events, e)
+	}
+	return s, nil
+}
+
+// normalizeID returns lowercase hex ID, left padded with zeros to the given length, as Jaeger omits leading zeros.
+func normalizeID(id string, length int) (string, error) {
+	if id == "" || len(id) > length || strings.Trim(strings.ToLower(id), "0123456789abcdef") != "" {
+		return "", errors.Errorf("invalid ID %q, expected at most %d hex characters", id, length)
+	}
+	return fmt.Sprintf("%0*s", length, strings.ToLower(id)), nil
+}
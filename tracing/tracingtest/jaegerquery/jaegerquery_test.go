@@ -0,0 +1,85 @@
+package jaegerquery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestClient(t *testing.T) {
+	traces, err := os.ReadFile("testdata/traces.json")
+	testutil.Ok(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/services":
+			_, _ = w.Write([]byte(`{"data":["app","jaeger-query"],"total":2,"limit":0,"offset":0,"errors":null}`))
+		case "/api/traces":
+			if r.URL.Query().Get("service") != "app" || r.URL.Query().Get("limit") != "10" || r.URL.Query().Get("tags") != `{"key":"value"}` {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":400,"msg":"unexpected query"}]}`))
+				return
+			}
+			_, _ = w.Write(traces)
+		case "/api/traces/4bf92f3577b34da6a3ce929d0e0e4736":
+			_, _ = w.Write(traces)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"data":null,"total":0,"limit":0,"offset":0,"errors":[{"code":404,"msg":"trace not found"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewClient(srv.URL + "/")
+
+	services, err := c.Services(ctx)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"app", "jaeger-query"}, services)
+
+	_, err = c.Traces(ctx, Query{Service: "other"})
+	testutil.NotOk(t, err)
+	testutil.Equals(t, "get "+srv.URL+"/api/traces?service=other: unexpected query (code 400)", err.Error())
+	_, err = c.Trace(ctx, "1")
+	testutil.NotOk(t, err)
+
+	spans, err := c.Traces(ctx, Query{Service: "app", Limit: 10, Tags: map[string]string{"key": "value"}})
+	testutil.Ok(t, err)
+	byID, err := c.Trace(ctx, "4bf92f3577b34da6a3ce929d0e0e4736")
+	testutil.Ok(t, err)
+	testutil.Equals(t, spans, byID)
+
+	tracingtest.AssertTree(t, spans, tracingtest.Node{
+		Name: "app", Status: "ok", Attributes: map[string]string{"group.goroutines": "1"}, Events: []string{},
+		Children: []tracingtest.Node{{
+			Name: "dummy operation", Status: "error: dummy error1", Attributes: map[string]string{"iterations": "42"}, Events: []string{"cache miss"},
+		}},
+	})
+
+	testutil.Equals(t, tracing.SpanKindInternal, spans.ByName("app")[0].Kind)
+
+	child := spans.ByName("dummy operation")[0]
+	testutil.Equals(t, tracingtest.Span{
+		Name:          "dummy operation",
+		Kind:          tracing.SpanKindClient,
+		TraceID:       "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:        "0000000000a902b7",
+		ParentSpanID:  "00f067aa0ba902b7",
+		LinkedSpanIDs: []string{"0000000000000001"},
+		Status:        "error: dummy error1",
+		Attributes:    map[string]string{"iterations": "42"},
+		Events: []tracingtest.Event{{
+			Name: "cache miss", Attributes: map[string]string{"key": "a"}, Time: time.UnixMicro(1650000000200000),
+		}},
+		Start:    time.UnixMicro(1650000000100000),
+		End:      time.UnixMicro(1650000001600000),
+		Resource: map[string]string{"service.name": "app", "host.name": "node-1"},
+	}, child)
+}
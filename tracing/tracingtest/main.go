@@ -0,0 +1,149 @@
+package tracingtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/pkg/errors"
+)
+
+// mainCtx is the context with the test binary root span, set by Main.
+var mainCtx context.Context
+
+// Main runs tests inside root span exported with the given exporter, then exits. Tests using T get their own child
+// spans. Use it in TestMain, e.g.
+//
+//	func TestMain(m *testing.M) {
+//		tracingtest.Main(m, otlp.Exporter(endpoint), tracing.WithServiceName("my-tests"))
+//	}
+func Main(m *testing.M, exporter tracing.ExporterBuilder, opts ...tracing.Option) {
+	tr, closeFn, err := tracing.NewTracer(exporter, opts...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tracingtest: create tracer:", err)
+		os.Exit(1)
+	}
+
+	ctx, root := tr.StartSpan("go test " + filepath.Base(os.Args[0]))
+	mainCtx = ctx
+
+	code := m.Run()
+	if code != 0 {
+		root.End(errors.Errorf("tests failed with exit code %d", code))
+	} else {
+		root.End(nil)
+	}
+	if err := closeFn(); err != nil {
+		fmt.Fprintln(os.Stderr, "tracingtest: close tracer:", err)
+	}
+	os.Exit(code)
+}
+
+// TracedT is testing.T, which runs test inside span. Log output is recorded as "log" events on the span.
+type TracedT struct {
+	*testing.T
+
+	ctx  context.Context
+	span tracing.Span
+}
+
+// T starts span for the test, which is ended when test finishes with the test status ("pass", "fail" or "skip") as
+// "test.status" attribute. Span is a child of the root span started by Main. Use TracedT.Run for subtests to trace
+// them as children spans and TracedT.SpanContext to nest the spans of the code under test.
+// T fails the test if Main was not used in TestMain, since without the tracer test spans would not be recorded.
+func T(t *testing.T) *TracedT {
+	if mainCtx == nil {
+		t.Helper()
+		t.Fatal("tracingtest: T requires tracingtest.Main in TestMain")
+	}
+	return newTracedT(mainCtx, t)
+}
+
+func newTracedT(ctx context.Context, t *testing.T) *TracedT {
+	ctx, span := tracing.StartSpan(ctx, t.Name())
+	t.Cleanup(func() {
+		switch {
+		case t.Skipped():
+			span.SetAttributes("test.status", "skip")
+			span.End(nil)
+		case t.Failed():
+			span.SetAttributes("test.status", "fail")
+			span.End(errors.New("test failed"))
+		default:
+			span.SetAttributes("test.status", "pass")
+			span.End(nil)
+		}
+	})
+	return &TracedT{T: t, ctx: ctx, span: span}
+}
+
+// SpanContext returns context with the test span. It's named differently than testing.T.Context, which is not
+// traced.
+func (t *TracedT) SpanContext() context.Context { return t.ctx }
+
+// Run runs `f` as a subtest of t inside the child span. See testing.T.Run.
+func (t *TracedT) Run(name string, f func(t *TracedT)) bool {
+	return t.T.Run(name, func(st *testing.T) { f(newTracedT(t.ctx, st)) })
+}
+
+func (t *TracedT) log(s string) { t.span.AddEvent("log", "message", strings.TrimSuffix(s, "\n")) }
+
+// Log is like testing.T.Log, but also records the message on the span.
+func (t *TracedT) Log(args ...interface{}) {
+	t.T.Helper()
+	t.log(fmt.Sprintln(args...))
+	t.T.Log(args...)
+}
+
+// Logf is like testing.T.Logf, but also records the message on the span.
+func (t *TracedT) Logf(format string, args ...interface{}) {
+	t.T.Helper()
+	t.log(fmt.Sprintf(format, args...))
+	t.T.Logf(format, args...)
+}
+
+// Error is like testing.T.Error, but also records the message on the span.
+func (t *TracedT) Error(args ...interface{}) {
+	t.T.Helper()
+	t.log(fmt.Sprintln(args...))
+	t.T.Error(args...)
+}
+
+// Errorf is like testing.T.Errorf, but also records the message on the span.
+func (t *TracedT) Errorf(format string, args ...interface{}) {
+	t.T.Helper()
+	t.log(fmt.Sprintf(format, args...))
+	t.T.Errorf(format, args...)
+}
+
+// Fatal is like testing.T.Fatal, but also records the message on the span.
+func (t *TracedT) Fatal(args ...interface{}) {
+	t.T.Helper()
+	t.log(fmt.Sprintln(args...))
+	t.T.Fatal(args...)
+}
+
+// Fatalf is like testing.T.Fatalf, but also records the message on the span.
+func (t *TracedT) Fatalf(format string, args ...interface{}) {
+	t.T.Helper()
+	t.log(fmt.Sprintf(format, args...))
+	t.T.Fatalf(format, args...)
+}
+
+// Skip is like testing.T.Skip, but also records the message on the span.
+func (t *TracedT) Skip(args ...interface{}) {
+	t.T.Helper()
+	t.log(fmt.Sprintln(args...))
+	t.T.Skip(args...)
+}
+
+// Skipf is like testing.T.Skipf, but also records the message on the span.
+func (t *TracedT) Skipf(format string, args ...interface{}) {
+	t.T.Helper()
+	t.log(fmt.Sprintf(format, args...))
+	t.T.Skipf(format, args...)
+}
@@ -0,0 +1,47 @@
+package tracingtest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+// mainRecorder records spans of all tests in this package.
+var mainRecorder = tracingtest.NewRecorder()
+
+func TestMain(m *testing.M) {
+	tracingtest.Main(m, mainRecorder.Exporter(), tracing.WithSynchronousExport())
+}
+
+func TestT(t *testing.T) {
+	tt := tracingtest.T(t)
+	tt.Run("pass", func(t *tracingtest.TracedT) {
+		t.Logf("hello %s", "world")
+		_ = tracing.DoInSpan(t.SpanContext(), "code under test", func(context.Context) error { return nil })
+	})
+	tt.Run("skip", func(t *tracingtest.TracedT) {
+		t.Skip("not now")
+	})
+
+	// Recorder is shared by all runs (e.g. with -count), so check only spans of this run.
+	sc := tracing.GetSpan(tt.SpanContext()).Context()
+	testutil.Assert(t, sc.IsSampled())
+	spans := mainRecorder.Spans()
+	subtests := spans.ChildrenOf(tracingtest.Span{TraceID: sc.TraceID(), SpanID: sc.SpanID()})
+	testutil.Equals(t, 2, len(subtests))
+
+	testSpan := subtests.ByName("TestT/pass")
+	testutil.Equals(t, 1, len(testSpan))
+	tracingtest.AssertTree(t, spans.ChildrenOf(testSpan[0]), tracingtest.Node{Name: "code under test"})
+
+	tracingtest.AssertTree(t, testSpan, tracingtest.Node{
+		Name: "TestT/pass", Status: "ok", Attributes: map[string]string{"test.status": "pass"}, Events: []string{"log"},
+	})
+	testutil.Equals(t, "hello world", testSpan[0].Events[0].Attributes["message"])
+	tracingtest.AssertTree(t, subtests.ByName("TestT/skip"), tracingtest.Node{
+		Name: "TestT/skip", Attributes: map[string]string{"test.status": "skip"}, Events: []string{"log"},
+	})
+}
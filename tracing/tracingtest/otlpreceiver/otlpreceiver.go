@@ -0,0 +1,233 @@
+// Package otlpreceiver provides in-process OTLP/gRPC trace receiver, so OTLP export can be tested end to end
+// without the collector.
+package otlpreceiver
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/pkg/errors"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	// Register gzip decompressor, so compressed requests can be received.
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+)
+
+// Request is the received export request.
+type Request struct {
+	// Metadata contains gRPC metadata (headers) of the request.
+	Metadata metadata.MD
+	// Compression is the name of the compressor used by the client (e.g. "gzip") or empty, if not compressed.
+	Compression string
+	Spans       tracingtest.Spans
+}
+
+// Receiver is an OTLP/gRPC trace receiver listening on the local port.
+type Receiver struct {
+	collectortracepb.UnimplementedTraceServiceServer
+
+	lis net.Listener
+	srv *grpc.Server
+
+	mu       sync.Mutex
+	requests []Request
+	errFn    func(Request) error
+}
+
+// Option sets the value of an option for a Receiver.
+type Option func(*options)
+
+type options struct {
+	tlsConfig  *tls.Config
+	serverOpts []grpc.ServerOption
+	errFn      func(Request) error
+}
+
+// WithTLS makes receiver serve TLS with the given configuration.
+func WithTLS(c *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = c
+	}
+}
+
+// WithServerOptions sets additional gRPC server options, e.g. interceptors.
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(o *options) {
+		o.serverOpts = append(o.serverOpts, opts...)
+	}
+}
+
+// WithExportError sets function that decides which error (if any) to return from the export request, e.g. to test
+// exporter retries. Requests resulting in errors are not recorded.
+func WithExportError(f func(Request) error) Option {
+	return func(o *options) {
+		o.errFn = f
+	}
+}
+
+// Start starts the receiver listening on the random local port.
+func Start(opts ...Option) (*Receiver, error) {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.Wrap(err, "listen")
+	}
+	serverOpts := o.serverOpts
+	if o.tlsConfig != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(o.tlsConfig)))
+	}
+
+	r := &Receiver{lis: lis, srv: grpc.NewServer(serverOpts...), errFn: o.errFn}
+	collectortracepb.RegisterTraceServiceServer(r.srv, r)
+	go func() { _ = r.srv.Serve(lis) }()
+	return r, nil
+}
+
+// Endpoint returns host:port address of the receiver.
+func (r *Receiver) Endpoint() string {
+	return r.lis.Addr().String()
+}
+
+// Export implements collectortracepb.TraceServiceServer.
+func (r *Receiver) Export(ctx context.Context, req *collectortracepb.ExportTraceServiceRequest) (*collectortracepb.ExportTraceServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	rq := Request{Metadata: md, Spans: toSpans(req.GetResourceSpans())}
+	if s, ok := grpc.ServerTransportStreamFromContext(ctx).(interface{ RecvCompress() string }); ok {
+		rq.Compression = s.RecvCompress()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.errFn != nil {
+		if err := r.errFn(rq); err != nil {
+			return nil, err
+		}
+	}
+	r.requests = append(r.requests, rq)
+	return &collectortracepb.ExportTraceServiceResponse{}, nil
+}
+
+// Requests returns all received (and not failed) export requests.
+func (r *Receiver) Requests() []Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Request(nil), r.requests...)
+}
+
+// Spans returns spans from all received (and not failed) export requests.
+func (r *Receiver) Spans() tracingtest.Spans {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var spans tracingtest.Spans
+	for _, rq := range r.requests {
+		spans = append(spans, rq.Spans...)
+	}
+	return spans
+}
+
+// Close stops the receiver.
+func (r *Receiver) Close() {
+	r.srv.Stop()
+}
+
+func toSpans(rss []*tracepb.ResourceSpans) tracingtest.Spans {
+	var spans tracingtest.Spans
+	for _, rs := range rss {
+		res := attrs(rs.GetResource().GetAttributes())
+		for _, ss := range rs.GetScopeSpans() {
+			for _, s := range ss.GetSpans() {
+				spans = append(spans, toSpan(s, res))
+			}
+		}
+		for _, ils := range rs.GetInstrumentationLibrarySpans() {
+			for _, s := range ils.GetSpans() {
+				spans = append(spans, toSpan(s, res))
+			}
+		}
+	}
+	return spans
+}
+
+func toSpan(s *tracepb.Span, res map[string]string) tracingtest.Span {
+	sp := tracingtest.Span{
+		Name:         s.GetName(),
+		Kind:         tracing.SpanKind(s.GetKind()),
+		TraceID:      hex.EncodeToString(s.GetTraceId()),
+		SpanID:       hex.EncodeToString(s.GetSpanId()),
+		ParentSpanID: hex.EncodeToString(s.GetParentSpanId()),
+		Attributes:   attrs(s.GetAttributes()),
+		Start:        time.Unix(0, int64(s.GetStartTimeUnixNano())),
+		End:          time.Unix(0, int64(s.GetEndTimeUnixNano())),
+		Resource:     res,
+	}
+	for _, l := range s.GetLinks() {
+		sp.LinkedSpanIDs = append(sp.LinkedSpanIDs, hex.EncodeToString(l.GetSpanId()))
+	}
+	switch s.GetStatus().GetCode() {
+	case tracepb.Status_STATUS_CODE_OK:
+		sp.Status = "ok"
+	case tracepb.Status_STATUS_CODE_ERROR:
+		sp.Status = "error: " + s.GetStatus().GetMessage()
+	default:
+		sp.Status = "unset"
+	}
+	for _, e := range s.GetEvents() {
+		sp.Events = append(sp.Events, tracingtest.Event{
+			Name:       e.GetName(),
+			Attributes: attrs(e.GetAttributes()),
+			Time:       time.Unix(0, int64(e.GetTimeUnixNano())),
+		})
+	}
+	return sp
+}
+
+func attrs(kvs []*commonpb.KeyValue) map[string]string {
+	ret := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		ret[kv.GetKey()] = value(kv.GetValue())
+	}
+	return ret
+}
+
+func value(v *commonpb.AnyValue) string {
+	switch x := v.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return x.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return strconv.FormatBool(x.BoolValue)
+	case *commonpb.AnyValue_IntValue:
+		return strconv.FormatInt(x.IntValue, 10)
+	case *commonpb.AnyValue_DoubleValue:
+		return strconv.FormatFloat(x.DoubleValue, 'g', -1, 64)
+	case *commonpb.AnyValue_BytesValue:
+		return string(x.BytesValue)
+	case *commonpb.AnyValue_ArrayValue:
+		vals := make([]string, 0, len(x.ArrayValue.GetValues()))
+		for _, av := range x.ArrayValue.GetValues() {
+			vals = append(vals, value(av))
+		}
+		return "[" + strings.Join(vals, ",") + "]"
+	case *commonpb.AnyValue_KvlistValue:
+		return fmt.Sprint(attrs(x.KvlistValue.GetValues()))
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package otlpreceiver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/exporters/otlp"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// selfSignedCert returns self-signed certificate for 127.0.0.1 and the pool with it.
+func selfSignedCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testutil.Ok(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	testutil.Ok(t, err)
+	cert, err := x509.ParseCertificate(der)
+	testutil.Ok(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func runApp(t *testing.T, exporter tracing.ExporterBuilder) {
+	t.Helper()
+
+	tr, closeFn, err := tracing.NewTracer(exporter, tracing.WithServiceName("app"))
+	testutil.Ok(t, err)
+	_ = tr.DoInSpan("root", func(ctx context.Context) error {
+		_, span := tracing.StartSpan(ctx, "child", tracing.WithStartSpanKind(tracing.SpanKindClient))
+		span.SetAttributes("key", "value")
+		span.AddEvent("event", "i", 1)
+		span.End(nil)
+		return nil
+	})
+	testutil.Ok(t, closeFn())
+}
+
+func TestReceiver(t *testing.T) {
+	t.Run("insecure with headers and compression", func(t *testing.T) {
+		r, err := Start()
+		testutil.Ok(t, err)
+		defer r.Close()
+
+		runApp(t, otlp.Exporter(
+			r.Endpoint(),
+			otlp.WithInsecure(),
+			otlp.WithHeaders(map[string]string{"x-tenant": "team-a"}),
+			otlp.WithDialOption(grpc.WithDefaultCallOptions(grpc.UseCompressor("gzip"))),
+		))
+
+		reqs := r.Requests()
+		testutil.Equals(t, 1, len(reqs))
+		testutil.Equals(t, []string{"team-a"}, reqs[0].Metadata.Get("x-tenant"))
+		testutil.Equals(t, "gzip", reqs[0].Compression)
+
+		spans := r.Spans()
+		tracingtest.AssertTree(t, spans, tracingtest.Node{Name: "root", Status: "ok", Children: []tracingtest.Node{
+			{Name: "child", Status: "ok", Attributes: map[string]string{"key": "value"}, Events: []string{"event"}},
+		}})
+		child := spans.ByName("child")[0]
+		testutil.Equals(t, tracing.SpanKindClient, child.Kind)
+		testutil.Equals(t, "app", child.Resource["service.name"])
+		testutil.Equals(t, "1", child.Events[0].Attributes["i"])
+		testutil.Equals(t, spans.ByName("root")[0].SpanID, child.ParentSpanID)
+		testutil.Assert(t, child.End.After(child.Start))
+	})
+	t.Run("TLS", func(t *testing.T) {
+		cert, pool := selfSignedCert(t)
+		r, err := Start(WithTLS(&tls.Config{Certificates: []tls.Certificate{cert}}))
+		testutil.Ok(t, err)
+		defer r.Close()
+
+		runApp(t, otlp.Exporter(r.Endpoint(), otlp.WithTLSCredentials(credentials.NewClientTLSFromCert(pool, ""))))
+		testutil.Equals(t, 2, len(r.Spans()))
+	})
+}
@@ -0,0 +1,207 @@
+// Package tracingtest provides utilities for testing instrumentation based on github.com/bwplotka/tracing-go/tracing
+// package, without running any tracing backend.
+package tracingtest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Span is a recorded span.
+type Span struct {
+	Name string
+	Kind tracing.SpanKind
+
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	// ParentRemote is true if the parent span was propagated from the remote process.
+	ParentRemote bool
+	// LinkedSpanIDs are IDs of spans linked to this span.
+	LinkedSpanIDs []string
+
+	// Status is either "unset", "ok" or "error: <description>".
+	Status     string
+	Attributes map[string]string
+	Events     []Event
+
+	Start, End time.Time
+	// Resource contains attributes of the resource (e.g. "service.name"), if available.
+	Resource map[string]string
+}
+
+// Event is a recorded span event.
+type Event struct {
+	Name       string
+	Attributes map[string]string
+	Time       time.Time
+}
+
+// Recorder records spans in memory.
+type Recorder struct {
+	mu    sync.Mutex
+	spans Spans
+}
+
+// NewRecorder returns new, empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Exporter returns exporter builder, which exports spans to the recorder.
+func (r *Recorder) Exporter() tracing.ExporterBuilder {
+	return func() (tracing.Exporter, error) { return recorderExporter{r: r}, nil }
+}
+
+// Spans returns all recorded spans in order they were ended.
+func (r *Recorder) Spans() Spans {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append(Spans(nil), r.spans...)
+}
+
+// Reset removes all recorded spans.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.spans = nil
+}
+
+type recorderExporter struct {
+	r *Recorder
+}
+
+func (e recorderExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.r.mu.Lock()
+	defer e.r.mu.Unlock()
+
+	for _, s := range spans {
+		e.r.spans = append(e.r.spans, fromReadOnlySpan(s))
+	}
+	return nil
+}
+
+// Shutdown is no-op, so spans can be inspected after tracer close.
+func (recorderExporter) Shutdown(context.Context) error { return nil }
+
+func fromReadOnlySpan(s sdktrace.ReadOnlySpan) Span {
+	sp := Span{
+		Name:       s.Name(),
+		Kind:       s.SpanKind(),
+		TraceID:    s.SpanContext().TraceID().String(),
+		SpanID:     s.SpanContext().SpanID().String(),
+		Attributes: map[string]string{},
+		Start:      s.StartTime(),
+		End:        s.EndTime(),
+	}
+	if s.Parent().SpanID().IsValid() {
+		sp.ParentSpanID = s.Parent().SpanID().String()
+		sp.ParentRemote = s.Parent().IsRemote()
+	}
+	for _, l := range s.Links() {
+		sp.LinkedSpanIDs = append(sp.LinkedSpanIDs, l.SpanContext.SpanID().String())
+	}
+	switch s.Status().Code {
+	case codes.Unset:
+		sp.Status = "unset"
+	case codes.Ok:
+		sp.Status = "ok"
+	default:
+		sp.Status = "error: " + s.Status().Description
+	}
+	if res := s.Resource(); res != nil {
+		sp.Resource = map[string]string{}
+		for _, a := range res.Attributes() {
+			sp.Resource[string(a.Key)] = a.Value.Emit()
+		}
+	}
+	for _, a := range s.Attributes() {
+		sp.Attributes[string(a.Key)] = a.Value.Emit()
+	}
+	for _, e := range s.Events() {
+		ev := Event{Name: e.Name, Attributes: map[string]string{}, Time: e.Time}
+		for _, a := range e.Attributes {
+			ev.Attributes[string(a.Key)] = a.Value.Emit()
+		}
+		sp.Events = append(sp.Events, ev)
+	}
+	return sp
+}
+
+// NewTracer returns Tracer which synchronously records all spans in the returned Recorder.
+// Tracer is closed on test cleanup.
+func NewTracer(t testing.TB, opts ...tracing.Option) (*tracing.Tracer, *Recorder) {
+	t.Helper()
+
+	r := NewRecorder()
+	tr, closeFn, err := tracing.NewTracer(r.Exporter(), append([]tracing.Option{tracing.WithSynchronousExport()}, opts...)...)
+	testutil.Ok(t, err)
+	t.Cleanup(func() { testutil.Ok(t, closeFn()) })
+	return tr, r
+}
+
+// Spans is a list of recorded spans.
+type Spans []Span
+
+// Filter returns spans matching given function.
+func (s Spans) Filter(f func(Span) bool) Spans {
+	var ret Spans
+	for _, sp := range s {
+		if f(sp) {
+			ret = append(ret, sp)
+		}
+	}
+	return ret
+}
+
+// ByName returns spans with the given name.
+func (s Spans) ByName(name string) Spans {
+	return s.Filter(func(sp Span) bool { return sp.Name == name })
+}
+
+// ByAttribute returns spans with the given attribute value.
+func (s Spans) ByAttribute(key, value string) Spans {
+	return s.Filter(func(sp Span) bool {
+		v, ok := sp.Attributes[key]
+		return ok && v == value
+	})
+}
+
+// ChildrenOf returns direct children of the given span.
+func (s Spans) ChildrenOf(parent Span) Spans {
+	return s.Filter(func(sp Span) bool { return sp.TraceID == parent.TraceID && sp.ParentSpanID == parent.SpanID })
+}
+
+// Roots returns spans without parent in the list (e.g. root spans or spans with remote parent).
+func (s Spans) Roots() Spans {
+	ids := map[string]struct{}{}
+	for _, sp := range s {
+		ids[sp.TraceID+sp.SpanID] = struct{}{}
+	}
+	return s.Filter(func(sp Span) bool {
+		_, ok := ids[sp.TraceID+sp.ParentSpanID]
+		return !ok
+	})
+}
+
+// sorted returns spans sorted by start time, then by name.
+func (s Spans) sorted() Spans {
+	ret := append(Spans(nil), s...)
+	sort.SliceStable(ret, func(i, j int) bool {
+		if !ret[i].Start.Equal(ret[j].Start) {
+			return ret[i].Start.Before(ret[j].Start)
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
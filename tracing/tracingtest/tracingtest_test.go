@@ -0,0 +1,76 @@
+package tracingtest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwplotka/tracing-go/tracing"
+	"github.com/bwplotka/tracing-go/tracing/tracingtest"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+type fakeTB struct {
+	testing.TB
+
+	failure string
+}
+
+func (*fakeTB) Helper() {}
+
+func (f *fakeTB) Fatal(args ...interface{}) { f.failure = fmt.Sprint(args...) }
+
+func TestTracer(t *testing.T) {
+	tr, rec := tracingtest.NewTracer(t)
+
+	_ = tr.DoInSpan("root", func(ctx context.Context) error {
+		_ = tracing.DoInSpan(ctx, "child", func(ctx context.Context) error {
+			tracing.GetSpan(ctx).SetAttributes("key", "value", "other", 1)
+			tracing.GetSpan(ctx).AddEvent("event")
+			return nil
+		})
+		return tracing.DoInSpan(ctx, "failing", func(ctx context.Context) error {
+			return errors.New("fail")
+		})
+	})
+	_, other := tr.StartSpan("other root")
+	other.End(nil)
+
+	// Spans are exported synchronously, so they are available right away.
+	spans := rec.Spans()
+	testutil.Equals(t, 4, len(spans))
+	testutil.Equals(t, 1, len(spans.ByName("child")))
+	testutil.Equals(t, "child", spans.ByAttribute("key", "value")[0].Name)
+	testutil.Equals(t, 0, len(spans.ByAttribute("key", "other")))
+	testutil.Equals(t, 2, len(spans.ChildrenOf(spans.ByName("root")[0])))
+	testutil.Equals(t, 2, len(spans.Roots()))
+	testutil.Equals(t, "error: fail", spans.ByName("failing")[0].Status)
+
+	tracingtest.AssertTree(t, spans,
+		tracingtest.Node{Name: "root", Status: "error: fail", Children: []tracingtest.Node{
+			{Name: "child", Status: "ok", Attributes: map[string]string{"key": "value"}, Events: []string{"event"}},
+			{Name: "failing"},
+		}},
+		tracingtest.Node{Name: "other root"},
+	)
+
+	tb := &fakeTB{}
+	tracingtest.AssertTree(tb, spans,
+		tracingtest.Node{Name: "root", Children: []tracingtest.Node{
+			{Name: "child", Attributes: map[string]string{"key": "other", "missing": "value"}, Events: []string{}},
+		}},
+	)
+	for _, diff := range []string{
+		`-  child {key="other", missing="value"} events=[]`,
+		`+  child {key="value", missing="<missing>"} events=[event]`,
+		`+  failing`,
+		`+other root`,
+	} {
+		testutil.Assert(t, strings.Contains(tb.failure, diff), "expected %q in %v", diff, tb.failure)
+	}
+
+	rec.Reset()
+	testutil.Equals(t, 0, len(rec.Spans()))
+}
@@ -0,0 +1,105 @@
+package tracingtest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+// Node is the expected span in the span tree.
+type Node struct {
+	Name string
+	// Status is the expected span status ("unset", "ok" or "error: <description>"). Not checked if empty.
+	Status string
+	// Attributes are expected span attributes. Attributes not specified here are not checked.
+	Attributes map[string]string
+	// Events are expected event names in order. Not checked if nil.
+	Events []string
+	// Children are expected children spans, ordered by start time (then name).
+	Children []Node
+}
+
+// AssertTree asserts that the spans form the expected trees (one per root span, ordered by start time).
+// On mismatch, it fails the test with the diff of the expected and actual trees. Only fields specified in
+// expected nodes are compared.
+func AssertTree(t testing.TB, spans Spans, expected ...Node) {
+	t.Helper()
+
+	exp, act := &strings.Builder{}, &strings.Builder{}
+	for _, n := range expected {
+		n.render(exp, 0)
+	}
+	renderActual(act, spans, spans.Roots().sorted(), expected, 0)
+	testutil.Equals(t, exp.String(), act.String())
+}
+
+func (n Node) render(w *strings.Builder, depth int) {
+	renderLine(w, depth, n.Name, n.Status, n.Attributes, n.Events)
+	for _, c := range n.Children {
+		c.render(w, depth+1)
+	}
+}
+
+func renderActual(w *strings.Builder, all, spans Spans, expected []Node, depth int) {
+	for i, sp := range spans {
+		if i >= len(expected) {
+			// Unexpected span, render just the name.
+			renderLine(w, depth, sp.Name, "", nil, nil)
+			renderActual(w, all, all.ChildrenOf(sp).sorted(), nil, depth+1)
+			continue
+		}
+
+		e := expected[i]
+		var status string
+		if e.Status != "" {
+			status = sp.Status
+		}
+		var attrs map[string]string
+		if len(e.Attributes) > 0 {
+			attrs = map[string]string{}
+			for k := range e.Attributes {
+				v, ok := sp.Attributes[k]
+				if !ok {
+					v = "<missing>"
+				}
+				attrs[k] = v
+			}
+		}
+		var events []string
+		if e.Events != nil {
+			events = []string{}
+			for _, ev := range sp.Events {
+				events = append(events, ev.Name)
+			}
+		}
+		renderLine(w, depth, sp.Name, status, attrs, events)
+		renderActual(w, all, all.ChildrenOf(sp).sorted(), e.Children, depth+1)
+	}
+}
+
+func renderLine(w *strings.Builder, depth int, name, status string, attrs map[string]string, events []string) {
+	w.WriteString(strings.Repeat("  ", depth))
+	w.WriteString(name)
+	if status != "" {
+		fmt.Fprintf(w, " [%s]", status)
+	}
+	if len(attrs) > 0 {
+		keys := make([]string, 0, len(attrs))
+		for k := range attrs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		kvs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			kvs = append(kvs, fmt.Sprintf("%s=%q", k, attrs[k]))
+		}
+		fmt.Fprintf(w, " {%s}", strings.Join(kvs, ", "))
+	}
+	if events != nil {
+		fmt.Fprintf(w, " events=[%s]", strings.Join(events, ", "))
+	}
+	w.WriteString("\n")
+}